@@ -10,6 +10,7 @@ import (
 
 	"reddit_v2/internal/core"
 	"reddit_v2/internal/handlers"
+	"reddit_v2/internal/keys"
 	"reddit_v2/internal/pg" // Импортируем нашу обертку
 	"reddit_v2/internal/routes"
 	"reddit_v2/internal/storage"
@@ -34,11 +35,27 @@ func main() {
 	// 4. Создание экземпляра хранилища с использованием нашей обертки
 	redditDB := storage.NewRedditDB(dbClient)
 
-	// 5. Создание сервиса и обработчиков
-	authService := core.New(redditDB)
+	// 5. Загрузка ключей подписи JWT
+	keySet, err := keys.LoadFromEnv(logger)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить ключи JWT: %v", err)
+	}
+
+	// 6. Создание сервиса и обработчиков
+	config, err := core.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Не удалось прочитать настройки: %v", err)
+	}
+	authService := core.New(redditDB, keySet, config, logger)
 	userHandler := handlers.NewUserHandler(authService)
 
-	// 6. Запуск сервера
+	// 7. Периодическая очистка удаленных постов и комментариев
+	go core.RunPurge(context.Background(), authService, config.PurgeInterval, logger)
+
+	// 8. Раздача событий потоков обновлений, опубликованных любым экземпляром
+	go core.RunEvents(context.Background(), authService, logger)
+
+	// 9. Запуск сервера
 	mux := routes.InitRoutes(userHandler)
 	fmt.Println("Запуск сервера на порту 8080 http://localhost:8080/")
 	http.ListenAndServe(":8080", mux)
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"strconv"
+	"strings"
+)
+
+// apiKeyPrefix позволяет отличить API-ключ от JWT в логах и конфигурации ботов
+const apiKeyPrefix = "rk_"
+
+func (s *service) AuthenticateAPIKey(ctx context.Context, key string) (*models.User, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, fmt.Errorf("неверный формат API-ключа")
+	}
+
+	user, err := s.storage.GetUserByAPIKey(HashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *service) CreateAPIKey(ctx context.Context, userID int, name string) (*models.APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("не указано название ключа")
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать API-ключ: %w", err)
+	}
+
+	key := &models.APIKey{
+		UserID: userID,
+		Name:   name,
+		Key:    apiKeyPrefix + secret,
+	}
+	if err := s.storage.CreateAPIKey(key, HashToken(key.Key)); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *service) GetAPIKeys(ctx context.Context, userID int) ([]*models.APIKey, error) {
+	return s.storage.GetAPIKeys(userID)
+}
+
+func (s *service) RevokeAPIKey(ctx context.Context, userID int, keyID string) error {
+	keyIDINT, err := strconv.Atoi(keyID)
+	if err != nil {
+		return err
+	}
+	return s.storage.RevokeAPIKey(userID, keyIDINT)
+}
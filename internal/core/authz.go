@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+)
+
+// ErrForbidden возвращается, когда у пользователя нет прав на действие
+var ErrForbidden = errors.New("недостаточно прав")
+
+// authorizeDelete разрешает удаление автору и модераторам
+func (s *service) authorizeDelete(userID int, owner storage.Owner) error {
+	if userID == owner.AuthorID {
+		return nil
+	}
+
+	return s.authorizeModerator(userID, owner.Category)
+}
+
+// authorizeEdit разрешает правку только автору, если он не заблокирован в категории
+func (s *service) authorizeEdit(userID int, owner storage.Owner) error {
+	if userID != owner.AuthorID {
+		return ErrForbidden
+	}
+	return s.authorizeCommunityWrite(userID, owner.Category)
+}
+
+// authorizeModerator пропускает модераторов категории и администраторов сайта.
+// Роль модератора сайта сама по себе прав в категориях не дает.
+func (s *service) authorizeModerator(userID int, category string) error {
+	role, err := s.storage.GetUserRole(userID)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleAdmin {
+		return nil
+	}
+
+	isModerator, err := s.storage.IsModerator(userID, category)
+	if err != nil {
+		return err
+	}
+	if isModerator {
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// authorizeModeratorView пропускает к истории правок модераторов категории и
+// администраторов, а также модераторов сайта: они просматривают записи любых
+// категорий, но ничего в них не меняют
+func (s *service) authorizeModeratorView(userID int, category string) error {
+	role, err := s.storage.GetUserRole(userID)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleModerator {
+		return nil
+	}
+	return s.authorizeModerator(userID, category)
+}
+
+func (s *service) SetUserRole(ctx context.Context, username string, role string) (*models.User, error) {
+	switch role {
+	case models.RoleUser, models.RoleModerator, models.RoleAdmin:
+	default:
+		return nil, fmt.Errorf("неизвестная роль %q", role)
+	}
+
+	user, err := s.storage.SetUserRole(username, role)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"errors"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"testing"
+)
+
+// fakeStorage отвечает только на запросы, нужные проверке прав.
+// Вызов любого другого метода storage.Interface завершится паникой.
+type fakeStorage struct {
+	storage.Interface
+	roles      map[int]string
+	moderators map[int][]string
+}
+
+func (f *fakeStorage) GetUserRole(userID int) (string, error) {
+	role, ok := f.roles[userID]
+	if !ok {
+		return models.RoleUser, nil
+	}
+	return role, nil
+}
+
+func (f *fakeStorage) IsModerator(userID int, category string) (bool, error) {
+	for _, c := range f.moderators[userID] {
+		if c == category {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestAuthorizeDelete(t *testing.T) {
+	const (
+		author = iota + 1
+		categoryModerator
+		otherModerator
+		siteAdmin
+		siteModerator
+		stranger
+	)
+
+	s := &service{storage: &fakeStorage{
+		roles: map[int]string{siteAdmin: models.RoleAdmin, siteModerator: models.RoleModerator},
+		moderators: map[int][]string{
+			categoryModerator: {"golang"},
+			otherModerator:    {"rust"},
+		},
+	}}
+	owner := storage.Owner{AuthorID: author, Category: "golang"}
+
+	tests := []struct {
+		name   string
+		userID int
+		want   error
+	}{
+		{"автор", author, nil},
+		{"модератор категории", categoryModerator, nil},
+		{"модератор другой категории", otherModerator, ErrForbidden},
+		{"администратор сайта", siteAdmin, nil},
+		{"модератор сайта", siteModerator, ErrForbidden},
+		{"посторонний пользователь", stranger, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.authorizeDelete(tt.userID, owner)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("authorizeDelete(%d) = %v, want %v", tt.userID, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeModeratorView(t *testing.T) {
+	const (
+		categoryModerator = iota + 1
+		siteModerator
+		stranger
+	)
+
+	s := &service{storage: &fakeStorage{
+		roles:      map[int]string{siteModerator: models.RoleModerator},
+		moderators: map[int][]string{categoryModerator: {"golang"}},
+	}}
+
+	tests := []struct {
+		name   string
+		userID int
+		want   error
+	}{
+		{"модератор категории", categoryModerator, nil},
+		{"модератор сайта", siteModerator, nil},
+		{"посторонний пользователь", stranger, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.authorizeModeratorView(tt.userID, "golang")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("authorizeModeratorView(%d) = %v, want %v", tt.userID, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+)
+
+// ErrBanned возвращается, когда заблокированный пользователь пытается что-то изменить
+var ErrBanned = errors.New("пользователь заблокирован")
+
+// maxBanDays — самый долгий временный бан; для более долгого используется бессрочный
+const maxBanDays = 3650
+
+// BanError описывает действующий бан. errors.Is(err, ErrBanned) для нее истинно.
+type BanError struct {
+	Ban *models.Ban
+}
+
+func (e *BanError) Error() string {
+	where := "на сайте"
+	if e.Ban.Category != "" {
+		where = "в категории " + e.Ban.Category
+	}
+
+	message := "Вы заблокированы " + where
+	if e.Ban.Expires != nil {
+		message += " до " + e.Ban.Expires.Format("02.01.2006 15:04")
+	}
+	if e.Ban.Reason != "" {
+		message += ": " + e.Ban.Reason
+	}
+	return message
+}
+
+func (e *BanError) Unwrap() error {
+	return ErrBanned
+}
+
+// CheckBan возвращает BanError, если пользователь заблокирован на всем сайте
+func (s *service) CheckBan(ctx context.Context, userID int) error {
+	return s.checkBan(userID, "")
+}
+
+// authorizeCommunityWrite не дает заблокированному в категории пользователю
+// публиковать, комментировать и голосовать в ней
+func (s *service) authorizeCommunityWrite(userID int, category string) error {
+	if category == "" {
+		return nil
+	}
+	return s.checkBan(userID, category)
+}
+
+func (s *service) checkBan(userID int, category string) error {
+	ban, err := s.storage.GetActiveBan(userID, category)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return &BanError{Ban: ban}
+	}
+	return nil
+}
+
+// authorizeBan пропускает к банам на всем сайте только администраторов,
+// а к банам в категории — ее модераторов
+func (s *service) authorizeBan(userID int, category string) error {
+	if category != "" {
+		return s.authorizeModerator(userID, category)
+	}
+
+	role, err := s.storage.GetUserRole(userID)
+	if err != nil {
+		return err
+	}
+	if role != models.RoleAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// BanUser блокирует пользователя на days дней, 0 — бессрочно.
+// Пустая category означает бан на всем сайте.
+func (s *service) BanUser(ctx context.Context, userID int, category string, username string, reason string, days int) (*models.Ban, error) {
+	if err := s.authorizeBan(userID, category); err != nil {
+		return nil, err
+	}
+	if days < 0 || days > maxBanDays {
+		return nil, fmt.Errorf("срок бана должен быть от 0 до %d дней", maxBanDays)
+	}
+
+	moderator, err := s.storage.GetUserName(userID)
+	if err != nil {
+		return nil, err
+	}
+	if moderator == username {
+		return nil, errors.New("нельзя заблокировать самого себя")
+	}
+
+	ban := &models.Ban{
+		Category: category,
+		Reason:   reason,
+		BannedBy: models.User{ID: userID, Username: moderator},
+	}
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: models.ModBan, Reason: reason}
+	if err := s.storage.BanUser(username, days, ban, entry); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+func (s *service) UnbanUser(ctx context.Context, userID int, category string, username string) error {
+	if err := s.authorizeBan(userID, category); err != nil {
+		return err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: models.ModUnban}
+	return s.storage.UnbanUser(username, category, entry)
+}
+
+func (s *service) GetBans(ctx context.Context, userID int, category string) ([]*models.Ban, error) {
+	if err := s.authorizeBan(userID, category); err != nil {
+		return nil, err
+	}
+
+	bans, err := s.storage.GetBans(category)
+	if err != nil {
+		return nil, err
+	}
+	if bans == nil {
+		bans = []*models.Ban{}
+	}
+	return bans, nil
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+	"strconv"
+)
+
+// ErrBlockSelf возвращается при попытке заблокировать самого себя
+var ErrBlockSelf = errors.New("нельзя заблокировать самого себя")
+
+// viewerID возвращает ID пользователя, от имени которого сделан запрос, или 0 для анонимов
+func viewerID(ctx context.Context) int {
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		return principal.ID
+	}
+	return 0
+}
+
+func (s *service) HidePost(ctx context.Context, userID int, idPost string) error {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.storage.GetPostOwner(idPostINT); err != nil {
+		return err
+	}
+	return s.storage.HidePost(userID, idPostINT)
+}
+
+func (s *service) UnhidePost(ctx context.Context, userID int, idPost string) error {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return err
+	}
+	return s.storage.UnhidePost(userID, idPostINT)
+}
+
+func (s *service) BlockUser(ctx context.Context, userID int, username string) error {
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok && principal.Username == username {
+		return ErrBlockSelf
+	}
+	return s.storage.BlockUser(userID, username)
+}
+
+func (s *service) UnblockUser(ctx context.Context, userID int, username string) error {
+	return s.storage.UnblockUser(userID, username)
+}
+
+func (s *service) GetBlockedUsers(ctx context.Context, userID int) ([]models.User, error) {
+	users, err := s.storage.GetBlockedUsers(userID)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+	return users, nil
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"sort"
+	"strconv"
+)
+
+// DefaultCommentDepth — сколько уровней ответов отдается, если клиент не указал depth
+const DefaultCommentDepth = 5
+
+// ErrThreadTooDeep возвращается при ответе глубже models.MaxCommentDepth
+var ErrThreadTooDeep = errors.New("превышена максимальная глубина ветки")
+
+func (s *service) GetCommentThread(ctx context.Context, idPost string, commentID string, opts models.CommentOptions) ([]models.Comment, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	commentIDINT, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizePostRead(ctx, post); err != nil {
+		return nil, err
+	}
+
+	depth := commentDepth(opts)
+	comments, err := s.storage.GetComments(idPostINT, commentIDINT, depth)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, storage.ErrCommentNotFound
+	}
+
+	if err := s.applyCommentVotes(ctx, idPostINT, comments); err != nil {
+		return nil, err
+	}
+
+	if err := s.maskRemovedComments(ctx, post.Category, comments); err != nil {
+		return nil, err
+	}
+
+	return arrangeComments(comments, opts, comments[0].Depth+depth), nil
+}
+
+func (s *service) UpdateCommentVote(ctx context.Context, idPost int, commentID int, vote *models.Vote) (*models.Post, error) {
+	owner, err := s.storage.GetCommentOwner(idPost, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwnerRead(ctx, owner); err != nil {
+		return nil, err
+	}
+	if owner.Deleted {
+		return nil, ErrDeleted
+	}
+	if err := s.authorizeCommunityWrite(vote.User, owner.Category); err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.UpdateCommentVote(idPost, commentID, vote)
+	if err != nil {
+		return nil, err
+	}
+	if score, ok := commentScore(post, commentID); ok {
+		if vote.Vote > 0 {
+			s.notifyMilestone(owner.AuthorID, idPost, &commentID, score)
+		}
+		s.publishEvent(models.EventScore, idPost, owner.Category, models.ScoreChange{PostID: idPost, CommentID: &commentID, Score: score})
+	}
+
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+// preparePost загружает голоса за пост и его комментарии, подставляет заглушки
+// вместо удаленных записей и приводит комментарии к запрошенному виду
+func (s *service) preparePost(ctx context.Context, post *models.Post, opts models.CommentOptions) (*models.Post, error) {
+	if err := s.attachVotes(ctx, []*models.Post{post}); err != nil {
+		return nil, err
+	}
+	if err := s.applyCommentVotes(ctx, post.ID, post.Comments); err != nil {
+		return nil, err
+	}
+	if err := s.maskRemovedComments(ctx, post.Category, post.Comments); err != nil {
+		return nil, err
+	}
+	maskDeletedPost(post)
+
+	post.Comments = arrangeComments(post.Comments, opts, commentDepth(opts))
+	return post, nil
+}
+
+// applyCommentVotes заполняет MyVote, Saved и Collapsed, если запрос сделан авторизованным пользователем
+func (s *service) applyCommentVotes(ctx context.Context, postID int, comments []models.Comment) error {
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok || len(comments) == 0 {
+		return nil
+	}
+
+	votes, err := s.storage.GetCommentVotesByUser(postID, principal.ID)
+	if err != nil {
+		return err
+	}
+	saved, err := s.storage.GetSavedComments(postID, principal.ID)
+	if err != nil {
+		return err
+	}
+	collapsed, err := s.storage.GetCollapsedComments(postID, principal.ID)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].MyVote = votes[comments[i].ID]
+		comments[i].Saved = saved[comments[i].ID]
+		comments[i].Collapsed = collapsed[comments[i].ID]
+	}
+	return nil
+}
+
+func commentDepth(opts models.CommentOptions) int {
+	if opts.Depth <= 0 {
+		return DefaultCommentDepth
+	}
+	if opts.Depth > models.MaxCommentDepth+1 {
+		return models.MaxCommentDepth + 1
+	}
+	return opts.Depth
+}
+
+// arrangeComments отбрасывает комментарии глубже depthLimit, помечает обрезанные
+// ветки, сортирует ответы одного уровня и возвращает дерево или плоский список
+// в порядке обхода дерева. comments должны идти в порядке обхода в глубину,
+// как их возвращает storage.GetComments.
+func arrangeComments(comments []models.Comment, opts models.CommentOptions, depthLimit int) []models.Comment {
+	type node struct {
+		comment  models.Comment
+		children []*node
+	}
+
+	nodes := make(map[int]*node, len(comments))
+	var roots []*node
+	for _, comment := range comments {
+		if comment.Depth >= depthLimit {
+			continue
+		}
+		if comment.Depth == depthLimit-1 && comment.ReplyCount > 0 {
+			comment.MoreReplies = true
+		}
+
+		n := &node{comment: comment}
+		nodes[comment.ID] = n
+
+		if comment.ParentID != nil {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.children = append(parent.children, n)
+				continue
+			}
+		}
+		roots = append(roots, n)
+	}
+
+	less := commentLess(opts.Sort)
+	flat := make([]models.Comment, 0, len(nodes))
+
+	var materialize func(list []*node) []models.Comment
+	materialize = func(list []*node) []models.Comment {
+		sort.SliceStable(list, func(i, j int) bool { return less(&list[i].comment, &list[j].comment) })
+
+		result := make([]models.Comment, 0, len(list))
+		for _, n := range list {
+			if opts.Flat {
+				flat = append(flat, n.comment)
+				materialize(n.children)
+				continue
+			}
+			n.comment.Replies = materialize(n.children)
+			result = append(result, n.comment)
+		}
+		return result
+	}
+
+	tree := materialize(roots)
+	if opts.Flat {
+		return flat
+	}
+	return tree
+}
+
+func commentLess(order string) func(a, b *models.Comment) bool {
+	switch order {
+	case models.CommentSortTop:
+		return func(a, b *models.Comment) bool {
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+			return a.Created.Before(b.Created)
+		}
+	case models.CommentSortNew:
+		return func(a, b *models.Comment) bool { return a.Created.After(b.Created) }
+	default:
+		return func(a, b *models.Comment) bool { return a.Created.Before(b.Created) }
+	}
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"regexp"
+	"strings"
+)
+
+// communityName — допустимое имя сообщества: латиница, цифры и подчеркивание
+var communityName = regexp.MustCompile(`^[A-Za-z0-9_]{3,21}$`)
+
+const maxCommunityRules = 15
+
+func (s *service) CreateCommunity(ctx context.Context, userID int, community *models.Community) error {
+	community.Name = strings.TrimSpace(community.Name)
+	if !communityName.MatchString(community.Name) {
+		return fmt.Errorf("имя сообщества должно состоять из 3–21 латинских букв, цифр или подчеркиваний")
+	}
+
+	switch community.Visibility {
+	case "":
+		community.Visibility = models.VisibilityPublic
+	case models.VisibilityPublic, models.VisibilityRestricted, models.VisibilityPrivate:
+	default:
+		return fmt.Errorf("неизвестная видимость сообщества %q", community.Visibility)
+	}
+
+	if len(community.Rules) > maxCommunityRules {
+		return fmt.Errorf("у сообщества может быть не больше %d правил", maxCommunityRules)
+	}
+	if community.Rules == nil {
+		community.Rules = []string{}
+	}
+
+	community.Creator = models.User{ID: userID}
+	if err := s.storage.CreateCommunity(community); err != nil {
+		return err
+	}
+
+	userName, err := s.storage.GetUserName(userID)
+	if err != nil {
+		return err
+	}
+	community.Creator.Username = userName
+	return nil
+}
+
+func (s *service) GetCommunities(ctx context.Context) ([]*models.Community, error) {
+	return s.storage.GetCommunities()
+}
+
+// GetCommunity возвращает описание сообщества. Закрытое сообщество для
+// посторонних выглядит несуществующим.
+func (s *service) GetCommunity(ctx context.Context, name string) (*models.Community, error) {
+	community, err := s.storage.GetCommunity(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeCommunityRead(ctx, community); err != nil {
+		return nil, err
+	}
+
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		community.Subscribed, err = s.storage.IsSubscribed(principal.ID, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return community, nil
+}
+
+func (s *service) Subscribe(ctx context.Context, userID int, name string) (*models.Community, error) {
+	if _, err := s.GetCommunity(ctx, name); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Subscribe(userID, name); err != nil {
+		return nil, err
+	}
+	return s.GetCommunity(ctx, name)
+}
+
+// Unsubscribe отписывает пользователя от сообщества. Отписаться можно и от
+// закрытого сообщества, к которому пользователь уже потерял доступ, поэтому
+// описание возвращается без проверки чтения.
+func (s *service) Unsubscribe(ctx context.Context, userID int, name string) (*models.Community, error) {
+	if err := s.storage.Unsubscribe(userID, name); err != nil {
+		return nil, err
+	}
+	return s.storage.GetCommunity(name)
+}
+
+// GetHomeFeed возвращает посты сообществ, на которые подписан пользователь.
+// Пока подписок нет, показывается общая лента.
+func (s *service) GetHomeFeed(ctx context.Context, userID int, req models.PageRequest) (*models.PostListing, error) {
+	hasSubscriptions, err := s.storage.HasSubscriptions(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSubscriptions {
+		return s.GetAllPosts(ctx, req)
+	}
+
+	return s.listPosts(ctx, req, models.SortHot, func(page models.Page) ([]*models.Post, error) {
+		return s.storage.GetSubscribedPosts(userID, page)
+	})
+}
+
+// authorizeCommunityRead пропускает к закрытому сообществу только его создателя
+// и модераторов
+func (s *service) authorizeCommunityRead(ctx context.Context, community *models.Community) error {
+	if community.Visibility != models.VisibilityPrivate {
+		return nil
+	}
+
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if ok && s.authorizeCommunityMember(principal.ID, community) == nil {
+		return nil
+	}
+	return storage.ErrCommunityNotFound
+}
+
+// authorizeCommunityPost проверяет, что пользователь может публиковать посты в сообществе
+func (s *service) authorizeCommunityPost(userID int, community *models.Community) error {
+	if community.Visibility == models.VisibilityPublic {
+		return nil
+	}
+
+	err := s.authorizeCommunityMember(userID, community)
+	if errors.Is(err, ErrForbidden) && community.Visibility == models.VisibilityPrivate {
+		return storage.ErrCommunityNotFound
+	}
+	return err
+}
+
+// authorizeCommunityOwner пропускает создателя сообщества и администраторов сайта
+func (s *service) authorizeCommunityOwner(userID int, community *models.Community) error {
+	if userID != 0 && userID == community.Creator.ID {
+		return nil
+	}
+	role, err := s.storage.GetUserRole(userID)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleAdmin {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// authorizeCommunityMember пропускает создателя сообщества и модераторов
+func (s *service) authorizeCommunityMember(userID int, community *models.Community) error {
+	if userID != 0 && userID == community.Creator.ID {
+		return nil
+	}
+	return s.authorizeModerator(userID, community.Name)
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultReportHideThreshold — после скольких открытых жалоб запись скрывается, если порог не задан
+	DefaultReportHideThreshold = 5
+	// DefaultDeletedRetention — сколько хранятся удаленные записи до окончательной очистки
+	DefaultDeletedRetention = 30 * 24 * time.Hour
+	// DefaultPurgeInterval — как часто запускается очистка удаленных записей
+	DefaultPurgeInterval = time.Hour
+	// DefaultMessageRateLimit — сколько личных сообщений пользователь может отправить за час
+	DefaultMessageRateLimit = 30
+)
+
+// Config — настройки сервиса, которые задаются переменными окружения
+type Config struct {
+	// ReportHideThreshold — число открытых жалоб, после которого пост или комментарий
+	// скрывается до решения модератора. 0 отключает автоматическое скрытие.
+	ReportHideThreshold int
+	// DeletedRetention — сколько удаленные записи хранятся для восстановления
+	DeletedRetention time.Duration
+	// PurgeInterval — период запуска очистки удаленных записей
+	PurgeInterval time.Duration
+	// MessageRateLimit — сколько личных сообщений пользователь может отправить
+	// за час. 0 снимает ограничение.
+	MessageRateLimit int
+}
+
+// ConfigFromEnv читает настройки из переменных окружения:
+// REPORT_HIDE_THRESHOLD — порог автоматического скрытия по жалобам,
+// MESSAGE_RATE_LIMIT — ограничение личных сообщений в час,
+// DELETED_RETENTION и PURGE_INTERVAL — срок хранения удаленных записей
+// и период их очистки в формате time.ParseDuration
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		ReportHideThreshold: DefaultReportHideThreshold,
+		DeletedRetention:    DefaultDeletedRetention,
+		PurgeInterval:       DefaultPurgeInterval,
+		MessageRateLimit:    DefaultMessageRateLimit,
+	}
+
+	for name, target := range map[string]*int{
+		"REPORT_HIDE_THRESHOLD": &cfg.ReportHideThreshold,
+		"MESSAGE_RATE_LIMIT":    &cfg.MessageRateLimit,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			return cfg, fmt.Errorf("неверное значение %s: %q", name, value)
+		}
+		*target = number
+	}
+
+	for name, target := range map[string]*time.Duration{
+		"DELETED_RETENTION": &cfg.DeletedRetention,
+		"PURGE_INTERVAL":    &cfg.PurgeInterval,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("неверное значение %s: %q", name, value)
+		}
+		*target = duration
+	}
+
+	return cfg, nil
+}
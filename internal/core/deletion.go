@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"strconv"
+	"time"
+)
+
+// ErrDeleted возвращается при попытке комментировать, оценить, сохранить или
+// обжаловать удаленную запись
+var ErrDeleted = errors.New("запись удалена")
+
+// moderatorDeletion возвращает запись журнала, если запись удаляет не автор, а модератор
+func moderatorDeletion(userID int, owner storage.Owner) *models.ModAction {
+	if userID == owner.AuthorID {
+		return nil
+	}
+	return &models.ModAction{Actor: models.User{ID: userID}, Action: models.ModRemove}
+}
+
+// RestorePost возвращает удаленный пост из последней сохраненной версии.
+// Доступно только администраторам, проверка роли — в маршрутах.
+func (s *service) RestorePost(ctx context.Context, userID int, idPost string) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: models.ModRestore}
+	if err := s.storage.RestorePost(idPostINT, entry); err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+// RestoreComment возвращает удаленный комментарий из последней сохраненной версии.
+// Доступно только администраторам, проверка роли — в маршрутах.
+func (s *service) RestoreComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	commentIDINT, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: models.ModRestore}
+	if err := s.storage.RestoreComment(idPostINT, commentIDINT, entry); err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+// PurgeDeleted окончательно удаляет записи, удаленные раньше срока хранения,
+// и заодно старые события потоков обновлений
+func (s *service) PurgeDeleted(ctx context.Context) (int64, int64, error) {
+	if _, err := s.storage.PruneEvents(eventRetention); err != nil {
+		return 0, 0, err
+	}
+	return s.storage.PurgeDeleted(s.config.DeletedRetention)
+}
+
+// RunPurge вызывает PurgeDeleted раз в interval, пока не отменен ctx
+func RunPurge(ctx context.Context, service Interface, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		posts, comments, err := service.PurgeDeleted(ctx)
+		if err != nil {
+			logger.Error("Не удалось очистить удаленные записи", "ошибка", err)
+		} else if posts > 0 || comments > 0 {
+			logger.Info("Удаленные записи очищены", "постов", posts, "комментариев", comments)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
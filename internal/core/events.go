@@ -0,0 +1,256 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reddit_v2/internal/models"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxEventBacklog — сколько пропущенных событий дочитывается при переподключении.
+	// Если пропущено больше, клиент получает событие reset и перезагружает пост целиком.
+	maxEventBacklog = 500
+	// eventBatch — сколько новых событий хаб читает из базы за один запрос
+	eventBatch = 100
+	// eventPollInterval — как часто хаб дочитывает события без уведомления. Событие,
+	// о котором пришло уведомление, может стать видимым только после завершения
+	// более старой транзакции, и нового уведомления об этом не будет.
+	eventPollInterval = time.Second
+	// eventBuffer — сколько событий может ждать отправки одному клиенту.
+	// Отстающего клиента отключают, и он дочитывает пропущенное по Last-Event-ID.
+	eventBuffer = 64
+	// eventRetention — сколько хранятся события для переподключения
+	eventRetention = 24 * time.Hour
+	// listenRetryInterval — пауза перед повторной подпиской на уведомления базы
+	listenRetryInterval = 5 * time.Second
+)
+
+// EventStream — подписка на события поста или категории
+type EventStream struct {
+	// Backlog — события после Last-Event-ID, пропущенные клиентом
+	Backlog []*models.Event
+	// Reset сообщает, что пропущено больше maxEventBacklog событий: Backlog пуст,
+	// клиент должен перезагрузить данные, а поток продолжится с позиции ResetPosition
+	Reset         bool
+	ResetPosition int64
+	// Events — новые события. Канал закрывается, если клиент не успевает их читать.
+	// Часть событий может совпасть с Backlog, их нужно пропускать по позиции.
+	Events <-chan *models.Event
+	// Close отменяет подписку
+	Close func()
+}
+
+// eventHub раздает события этого экземпляра приложения подписанным клиентам
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[*eventSubscriber]struct{}
+
+	// deliverMu выстраивает чтение новых событий и подписку в очередь
+	deliverMu sync.Mutex
+	// horizon — позиция последнего разосланного события; 0, пока подписчиков нет
+	horizon int64
+}
+
+type eventSubscriber struct {
+	filter models.EventFilter
+	events chan *models.Event
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: map[*eventSubscriber]struct{}{}}
+}
+
+func (h *eventHub) subscribe(filter models.EventFilter) *eventSubscriber {
+	sub := &eventSubscriber{filter: filter, events: make(chan *models.Event, eventBuffer)}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *eventHub) unsubscribe(sub *eventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (h *eventHub) empty() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers) == 0
+}
+
+// broadcast отправляет событие подходящим подписчикам, не блокируясь на отстающих
+func (h *eventHub) broadcast(event *models.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// publishEvent сохраняет событие поста. Действие, вызвавшее событие, уже
+// выполнено, поэтому ошибка только записывается в лог.
+func (s *service) publishEvent(kind string, postID int, category string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		s.logger.Warn("Не удалось закодировать событие", "тип", kind, "ошибка", err)
+		return
+	}
+
+	event := &models.Event{Type: kind, PostID: postID, Category: category, Data: payload}
+	if err := s.storage.PublishEvent(event); err != nil {
+		s.logger.Warn("Не удалось опубликовать событие", "тип", kind, "ошибка", err)
+	}
+}
+
+func (s *service) SubscribePostEvents(ctx context.Context, idPost string, lastEventID int64) (*EventStream, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizePostRead(ctx, post); err != nil {
+		return nil, err
+	}
+	return s.subscribeEvents(models.EventFilter{PostID: idPostINT}, lastEventID)
+}
+
+func (s *service) SubscribeCommunityEvents(ctx context.Context, category string, lastEventID int64) (*EventStream, error) {
+	if _, err := s.GetCommunity(ctx, category); err != nil {
+		return nil, err
+	}
+	return s.subscribeEvents(models.EventFilter{Category: category}, lastEventID)
+}
+
+// subscribeEvents подписывается на новые события раньше, чем читает пропущенные,
+// чтобы между двумя шагами ничего не потерялось
+func (s *service) subscribeEvents(filter models.EventFilter, lastEventID int64) (*EventStream, error) {
+	sub, horizon, err := s.subscribeHub(filter)
+	if err != nil {
+		return nil, err
+	}
+	stream := &EventStream{Events: sub.events, Close: func() { s.events.unsubscribe(sub) }}
+
+	if lastEventID > 0 {
+		backlog, err := s.storage.GetEventsSince(lastEventID, filter, maxEventBacklog+1)
+		if err != nil {
+			stream.Close()
+			return nil, err
+		}
+		if len(backlog) > maxEventBacklog {
+			// Хаб разошлет все события после horizon, поэтому поток продолжится с нее
+			stream.Reset, stream.ResetPosition = true, horizon
+		} else {
+			stream.Backlog = backlog
+		}
+	}
+	return stream, nil
+}
+
+// subscribeHub добавляет подписчика и возвращает позицию, после которой хаб
+// разошлет ему все события. Первый подписчик начинает с горизонта базы.
+func (s *service) subscribeHub(filter models.EventFilter) (*eventSubscriber, int64, error) {
+	s.events.deliverMu.Lock()
+	defer s.events.deliverMu.Unlock()
+
+	if s.events.horizon == 0 {
+		horizon, err := s.storage.GetEventHorizon()
+		if err != nil {
+			return nil, 0, err
+		}
+		s.events.horizon = horizon
+	}
+	return s.events.subscribe(filter), s.events.horizon, nil
+}
+
+// deliverEvents рассылает подписчикам все события после горизонта хаба. Без
+// подписчиков горизонт сбрасывается, чтобы не читать события, которые никому
+// не нужны.
+func (s *service) deliverEvents() {
+	s.events.deliverMu.Lock()
+	defer s.events.deliverMu.Unlock()
+
+	if s.events.empty() {
+		s.events.horizon = 0
+		return
+	}
+
+	for {
+		events, err := s.storage.GetNewEvents(s.events.horizon, eventBatch)
+		if err != nil {
+			s.logger.Warn("Не удалось прочитать новые события", "ошибка", err)
+			return
+		}
+		for _, event := range events {
+			s.events.broadcast(event)
+			s.events.horizon = event.Position
+		}
+		if len(events) < eventBatch {
+			return
+		}
+	}
+}
+
+// ListenEvents раздает подписчикам этого экземпляра новые события по
+// уведомлениям базы и раз в eventPollInterval. Горизонт хаба переживает обрыв
+// подписки, поэтому после переподключения пропущенное за это время дочитывается.
+// Блокируется до отмены ctx или обрыва подключения.
+func (s *service) ListenEvents(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(eventPollInterval)
+		defer ticker.Stop()
+		for {
+			s.deliverEvents()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return s.storage.ListenEvents(ctx, s.deliverEvents)
+}
+
+// RunEvents держит подписку ListenEvents, переподключаясь после обрывов, пока не отменен ctx
+func RunEvents(ctx context.Context, service Interface, logger *slog.Logger) {
+	for {
+		err := service.ListenEvents(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("Подписка на события прервана, повтор", "ошибка", err, "через", listenRetryInterval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
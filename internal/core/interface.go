@@ -3,98 +3,270 @@ package core
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"reddit_v2/internal/keys"
 	"reddit_v2/internal/middleware"
 	"reddit_v2/internal/models"
 	"reddit_v2/internal/storage"
 	"strconv"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
 type Interface interface {
-	Register(ctx context.Context, user *models.User) (string, error)
-	Login(ctx context.Context, user *models.User) (string, error)
-	GetAllPosts(ctx context.Context) ([]*models.Post, error)
+	Register(ctx context.Context, user *models.User) (*middleware.RegisterResponse, error)
+	Login(ctx context.Context, user *models.User) (*middleware.RegisterResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*middleware.RegisterResponse, error)
+	Logout(ctx context.Context, sessionID string) error
+	CheckSession(ctx context.Context, sessionID string) error
+	ParseToken(ctx context.Context, tokenString string) (*middleware.TokenClaims, error)
+	JWKS(ctx context.Context) keys.JWKS
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.User, error)
+	CreateAPIKey(ctx context.Context, userID int, name string) (*models.APIKey, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID int, keyID string) error
+	SetUserRole(ctx context.Context, username string, role string) (*models.User, error)
+	EditPost(ctx context.Context, userID int, idPost string, edit *models.PostEdit) (*models.Post, error)
+	EditComment(ctx context.Context, userID int, idPost string, commentID string, body string) (*models.Post, error)
+	GetPostRevisions(ctx context.Context, userID int, idPost string) ([]*models.Revision, error)
+	GetCommentRevisions(ctx context.Context, userID int, idPost string, commentID string) ([]*models.Revision, error)
+	GetAllPosts(ctx context.Context, req models.PageRequest) (*models.PostListing, error)
 	NewPost(ctx context.Context, post *models.Post) error
-	GetPost(ctx context.Context, post_ID string) (*models.Post, error)
-	GetPostsByCategory(ctx context.Context, category string) ([]*models.Post, error)
-	GetPostsByUserLogin(ctx context.Context, category string) ([]*models.Post, error)
+	GetPost(ctx context.Context, post_ID string, opts models.CommentOptions) (*models.Post, error)
+	GetCommentThread(ctx context.Context, idPost string, commentID string, opts models.CommentOptions) ([]models.Comment, error)
+	GetPostsByCategory(ctx context.Context, category string, req models.PageRequest) (*models.PostListing, error)
+	GetPostsByUserLogin(ctx context.Context, username string, req models.PageRequest) (*models.PostListing, error)
 	GetUserName(ctx context.Context, authorID int) (string, error)
+	Search(ctx context.Context, q models.SearchQuery, req models.PageRequest) (*models.SearchListing, error)
 	AddComment(ctx context.Context, idPost string, comment *models.Comment) (*models.Post, error)
-	DeleteComment(ctx context.Context, idPost string, commentID string) (*models.Post, error)
-	DeletePost(ctx context.Context, idPost string) ([]*models.Post, error)
+	DeleteComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error)
+	DeletePost(ctx context.Context, userID int, idPost string) ([]*models.Post, error)
 	UpdateVote(ctx context.Context, idPost int, vote *models.Vote) (*models.Post, error)
+	UpdateCommentVote(ctx context.Context, idPost int, commentID int, vote *models.Vote) (*models.Post, error)
+	CreateCommunity(ctx context.Context, userID int, community *models.Community) error
+	GetCommunities(ctx context.Context) ([]*models.Community, error)
+	GetCommunity(ctx context.Context, name string) (*models.Community, error)
+	Subscribe(ctx context.Context, userID int, name string) (*models.Community, error)
+	Unsubscribe(ctx context.Context, userID int, name string) (*models.Community, error)
+	GetHomeFeed(ctx context.Context, userID int, req models.PageRequest) (*models.PostListing, error)
+	ModeratePost(ctx context.Context, userID int, idPost string, action string, reason string) (*models.Post, error)
+	ModerateComment(ctx context.Context, userID int, idPost string, commentID string, action string, reason string) (*models.Post, error)
+	GetModerators(ctx context.Context, category string) ([]models.User, error)
+	AddModerator(ctx context.Context, userID int, category string, username string) ([]models.User, error)
+	RemoveModerator(ctx context.Context, userID int, category string, username string) ([]models.User, error)
+	GetModLog(ctx context.Context, userID int, category string, req models.PageRequest) (*models.ModLogListing, error)
+	GetModQueue(ctx context.Context, userID int, category string, itemType string, req models.PageRequest) (*models.ModQueueListing, error)
+	ReportPost(ctx context.Context, userID int, idPost string, reason string, details string) (*models.Report, error)
+	ReportComment(ctx context.Context, userID int, idPost string, commentID string, reason string, details string) (*models.Report, error)
+	GetReports(ctx context.Context, userID int, category string, req models.PageRequest) (*models.ReportListing, error)
+	CloseReport(ctx context.Context, userID int, reportID string, action string, reason string) (*models.Report, error)
+	CheckBan(ctx context.Context, userID int) error
+	BanUser(ctx context.Context, userID int, category string, username string, reason string, days int) (*models.Ban, error)
+	UnbanUser(ctx context.Context, userID int, category string, username string) error
+	GetBans(ctx context.Context, userID int, category string) ([]*models.Ban, error)
+	RestorePost(ctx context.Context, userID int, idPost string) (*models.Post, error)
+	RestoreComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error)
+	PurgeDeleted(ctx context.Context) (int64, int64, error)
+	GetUserProfile(ctx context.Context, username string) (*models.UserProfile, error)
+	GetVotedPosts(ctx context.Context, username string, vote int, req models.PageRequest) (*models.PostListing, error)
+	GetVotedComments(ctx context.Context, username string, vote int, req models.PageRequest) (*models.UserCommentListing, error)
+	GetUserComments(ctx context.Context, username string, req models.PageRequest) (*models.UserCommentListing, error)
+	SavePost(ctx context.Context, userID int, idPost string) (*models.Post, error)
+	UnsavePost(ctx context.Context, userID int, idPost string) (*models.Post, error)
+	SaveComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error)
+	UnsaveComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error)
+	GetSaved(ctx context.Context, username string, filter models.SavedFilter, req models.PageRequest) (*models.SavedListing, error)
+	HidePost(ctx context.Context, userID int, idPost string) error
+	UnhidePost(ctx context.Context, userID int, idPost string) error
+	BlockUser(ctx context.Context, userID int, username string) error
+	UnblockUser(ctx context.Context, userID int, username string) error
+	GetBlockedUsers(ctx context.Context, userID int) ([]models.User, error)
+	GetNotifications(ctx context.Context, userID int, unreadOnly bool, req models.PageRequest) (*models.NotificationListing, error)
+	MarkNotificationsRead(ctx context.Context, userID int, ids []int) error
+	MarkNotificationRead(ctx context.Context, userID int, notificationID string) error
+	CountUnread(ctx context.Context, userID int) (*models.UnreadCount, error)
+	SubscribePostEvents(ctx context.Context, idPost string, lastEventID int64) (*EventStream, error)
+	SubscribeCommunityEvents(ctx context.Context, category string, lastEventID int64) (*EventStream, error)
+	ListenEvents(ctx context.Context) error
+	SendMessage(ctx context.Context, userID int, username string, body string) (*models.Message, error)
+	GetConversations(ctx context.Context, userID int, req models.PageRequest) (*models.ConversationListing, error)
+	GetMessages(ctx context.Context, userID int, username string, req models.PageRequest) (*models.MessageListing, error)
+	MarkMessagesRead(ctx context.Context, userID int, username string) error
+	DeleteMessage(ctx context.Context, userID int, messageID string) error
 }
 
 type service struct {
 	storage storage.Interface
+	keys    *keys.KeySet
+	config  Config
+	events  *eventHub
+	logger  *slog.Logger // для ошибок побочных действий, которые не прерывают запрос
 }
 
-func New(storage storage.Interface) Interface {
+func New(storage storage.Interface, keySet *keys.KeySet, config Config, logger *slog.Logger) Interface {
 	return &service{
 		storage: storage,
+		keys:    keySet,
+		config:  config,
+		events:  newEventHub(),
+		logger:  logger,
 	}
 }
 
-func (s *service) Register(ctx context.Context, user *models.User) (string, error) {
+func (s *service) Register(ctx context.Context, user *models.User) (*middleware.RegisterResponse, error) {
 	// Хэшируем пароль перед сохранением
 	hashedPassword, err := HashPassword(user.Password)
 	if err != nil {
-		return "", fmt.Errorf("не удалось хэшировать пароль: %w", err)
+		return nil, fmt.Errorf("не удалось хэшировать пароль: %w", err)
 	}
 	user.Password = hashedPassword // Сохраняем хэшированный пароль
 
 	err = s.storage.Register(user)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.GenerateTokenClaims(user))
-	tokenString, err := jwtToken.SignedString(middleware.SecretKey)
+	return s.startSession(user)
+}
+
+func (s *service) Login(ctx context.Context, user *models.User) (*middleware.RegisterResponse, error) {
+	foundUser, err := s.storage.Login(user)
 	if err != nil {
-		return "", fmt.Errorf("не удалось создать токен")
+		return nil, err
 	}
 
-	return tokenString, nil
+	if !CheckPasswordHash(user.Password, foundUser.Password) {
+		return nil, fmt.Errorf("неверный пароль")
+	}
+
+	return s.startSession(&foundUser)
 }
 
-func (s *service) Login(ctx context.Context, user *models.User) (string, error) {
-	foundUser, err := s.storage.Login(user)
+// Refresh обменивает refresh-токен на новую пару токенов.
+// Старый refresh-токен после этого становится недействительным.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*middleware.RegisterResponse, error) {
+	newRefreshToken, err := randomToken(32)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("не удалось создать refresh-токен: %w", err)
 	}
 
-	if !CheckPasswordHash(user.Password, foundUser.Password) {
-		return "", fmt.Errorf("неверный пароль")
+	newSession := &models.Session{
+		TokenHash: HashToken(newRefreshToken),
+		Created:   time.Now(),
+		Expires:   time.Now().Add(middleware.RefreshTokenTTL),
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.GenerateTokenClaims(&foundUser))
-	tokenString, err := jwtToken.SignedString(middleware.SecretKey)
+	user, err := s.storage.RotateSession(HashToken(refreshToken), newSession)
 	if err != nil {
-		return "", fmt.Errorf("не удалось создать токен")
+		return nil, err
 	}
 
-	return tokenString, nil
+	accessToken, err := s.signAccessToken(&user, newSession.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &middleware.RegisterResponse{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
+}
+
+func (s *service) Logout(ctx context.Context, sessionID string) error {
+	return s.storage.RevokeSession(sessionID)
+}
+
+// CheckSession возвращает ошибку, если сессия была отозвана
+func (s *service) CheckSession(ctx context.Context, sessionID string) error {
+	active, err := s.storage.IsSessionActive(sessionID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return fmt.Errorf("сессия отозвана")
+	}
+	return nil
 }
 
-func (s *service) GetAllPosts(ctx context.Context) ([]*models.Post, error) {
-	posts, err := s.storage.GetAllPosts()
+// startSession открывает новое семейство сессий и выдает первую пару токенов
+func (s *service) startSession(user *models.User) (*middleware.RegisterResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать сессию: %w", err)
+	}
 
+	refreshToken, err := randomToken(32)
 	if err != nil {
+		return nil, fmt.Errorf("не удалось создать refresh-токен: %w", err)
+	}
+
+	session := &models.Session{
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: HashToken(refreshToken),
+		Expires:   time.Now().Add(middleware.RefreshTokenTTL),
+	}
+	if err := s.storage.CreateSession(session); err != nil {
 		return nil, err
 	}
-	return posts, nil
+
+	accessToken, err := s.signAccessToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &middleware.RegisterResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// ParseToken проверяет подпись и срок действия access-токена
+func (s *service) ParseToken(ctx context.Context, tokenString string) (*middleware.TokenClaims, error) {
+	claims := &middleware.TokenClaims{}
+	jwtToken, err := jwt.ParseWithClaims(tokenString, claims, s.keys.Keyfunc)
+	if err != nil || !jwtToken.Valid {
+		return nil, fmt.Errorf("неверный токен")
+	}
+
+	return claims, nil
+}
+
+func (s *service) JWKS(ctx context.Context) keys.JWKS {
+	return s.keys.JWKS()
+}
+
+func (s *service) signAccessToken(user *models.User, sessionID string) (string, error) {
+	tokenString, err := s.keys.Sign(middleware.GenerateTokenClaims(user, sessionID))
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать токен")
+	}
+
+	return tokenString, nil
+}
+
+func (s *service) GetAllPosts(ctx context.Context, req models.PageRequest) (*models.PostListing, error) {
+	return s.listPosts(ctx, req, models.SortHot, func(page models.Page) ([]*models.Post, error) {
+		return s.storage.GetAllPosts(page)
+	})
 }
 
 func (s *service) NewPost(ctx context.Context, post *models.Post) error {
-	err := s.storage.NewPost(post)
+	community, err := s.storage.GetCommunity(post.Category)
 	if err != nil {
 		return err
 	}
+	if err := s.authorizeCommunityPost(post.Author.ID, community); err != nil {
+		return err
+	}
+	if err := s.authorizeCommunityWrite(post.Author.ID, community.Name); err != nil {
+		return err
+	}
+
+	err = s.storage.NewPost(post)
+	if err != nil {
+		return err
+	}
+	post.Votes = []models.Vote{}
 	return nil
 }
 
-func (s *service) GetPost(ctx context.Context, post_ID string) (*models.Post, error) {
+func (s *service) GetPost(ctx context.Context, post_ID string, opts models.CommentOptions) (*models.Post, error) {
 	intPostID, err := strconv.Atoi(post_ID)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось создать преобразовать ID поста")
@@ -103,25 +275,75 @@ func (s *service) GetPost(ctx context.Context, post_ID string) (*models.Post, er
 	if err != nil {
 		return nil, err
 	}
-	return post, nil
+	if err := s.authorizePostRead(ctx, post); err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, opts)
 }
 
-func (s *service) GetPostsByCategory(ctx context.Context, category string) ([]*models.Post, error) {
-	posts, err := s.storage.GetPostsByCategory(category)
+// authorizePostRead скрывает посты приватных сообществ от посторонних, а
+// удаленные модераторами посты — от всех, кроме модераторов категории
+func (s *service) authorizePostRead(ctx context.Context, post *models.Post) error {
+	return s.authorizeRead(ctx, post.Category, post.Removed)
+}
 
-	if err != nil {
-		return nil, err
-	}
-	return posts, nil
+// authorizeOwnerRead — проверка authorizePostRead по владельцу записи. Писать
+// можно только в посты, которые пользователь видит.
+func (s *service) authorizeOwnerRead(ctx context.Context, owner storage.Owner) error {
+	return s.authorizeRead(ctx, owner.Category, owner.Removed)
 }
 
-func (s *service) GetPostsByUserLogin(ctx context.Context, username string) ([]*models.Post, error) {
-	posts, err := s.storage.GetPostsByUserLogin(username)
+func (s *service) authorizeRead(ctx context.Context, category string, removed bool) error {
+	if category != "" {
+		community, err := s.storage.GetCommunity(category)
+		if err != nil {
+			return err
+		}
+		if err := s.authorizeCommunityRead(ctx, community); err != nil {
+			return err
+		}
+	}
+	if removed {
+		moderates, err := s.viewerModerates(ctx, category)
+		if err != nil {
+			return err
+		}
+		if !moderates {
+			return storage.ErrPostNotFound
+		}
+	}
+	return nil
+}
 
+func (s *service) GetPostsByCategory(ctx context.Context, category string, req models.PageRequest) (*models.PostListing, error) {
+	if _, err := s.GetCommunity(ctx, category); err != nil {
+		return nil, err
+	}
+	listing, err := s.listPosts(ctx, req, models.SortHot, func(page models.Page) ([]*models.Post, error) {
+		return s.storage.GetPostsByCategory(category, page)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return posts, nil
+
+	// Закрепленные посты не участвуют в сортировке и идут перед первой страницей
+	if req.After == "" && req.Before == "" {
+		pinned, err := s.storage.GetPinnedPosts(category, viewerID(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.attachVotes(ctx, pinned); err != nil {
+			return nil, err
+		}
+		listing.Posts = append(pinned, listing.Posts...)
+	}
+	return listing, nil
+}
+
+func (s *service) GetPostsByUserLogin(ctx context.Context, username string, req models.PageRequest) (*models.PostListing, error) {
+	return s.listPosts(ctx, req, models.SortNew, func(page models.Page) ([]*models.Post, error) {
+		return s.storage.GetPostsByUserLogin(username, page)
+	})
 }
 
 func (s *service) GetUserName(ctx context.Context, authorID int) (string, error) {
@@ -139,16 +361,54 @@ func (s *service) AddComment(ctx context.Context, idPost string, comment *models
 	if err != nil {
 		return nil, err
 	}
+
+	owner, err := s.storage.GetPostOwner(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwnerRead(ctx, owner); err != nil {
+		return nil, err
+	}
+	if owner.Deleted {
+		return nil, ErrDeleted
+	}
+	if err := s.authorizeComment(comment.Author.ID, owner); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeCommunityWrite(comment.Author.ID, owner.Category); err != nil {
+		return nil, err
+	}
+
+	if comment.ParentID != nil {
+		parent, err := s.storage.GetCommentOwner(idPostINT, *comment.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Deleted {
+			return nil, ErrDeleted
+		}
+		parentDepth, err := s.storage.GetCommentDepth(idPostINT, *comment.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parentDepth+1 > models.MaxCommentDepth {
+			return nil, ErrThreadTooDeep
+		}
+		comment.Depth = parentDepth + 1
+	}
+
 	post, err := s.storage.AddComment(idPostINT, comment)
 
 	if err != nil {
 		return nil, err
 	}
+	s.notifyComment(idPostINT, owner, comment)
+	s.publishEvent(models.EventComment, idPostINT, owner.Category, comment)
 
-	return post, nil
+	return s.preparePost(ctx, post, models.CommentOptions{})
 }
 
-func (s *service) DeleteComment(ctx context.Context, idPost string, commentID string) (*models.Post, error) {
+func (s *service) DeleteComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error) {
 	idPostINT, err := strconv.Atoi(idPost)
 	if err != nil {
 		return nil, err
@@ -159,33 +419,74 @@ func (s *service) DeleteComment(ctx context.Context, idPost string, commentID st
 		return nil, err
 	}
 
-	post, err := s.storage.DeleteComment(idPostINT, commentIDINT)
+	owner, err := s.storage.GetCommentOwner(idPostINT, commentIDINT)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.authorizeDelete(userID, owner); err != nil {
+		return nil, err
+	}
 
-	return post, nil
+	post, err := s.storage.DeleteComment(idPostINT, commentIDINT, userID, moderatorDeletion(userID, owner))
+	if err != nil {
+		return nil, err
+	}
+	s.publishEvent(models.EventDelete, idPostINT, owner.Category, models.Deletion{PostID: idPostINT, CommentID: &commentIDINT})
+
+	return s.preparePost(ctx, post, models.CommentOptions{})
 }
 
-func (s *service) DeletePost(ctx context.Context, idPost string) ([]*models.Post, error) {
+func (s *service) DeletePost(ctx context.Context, userID int, idPost string) ([]*models.Post, error) {
 	idPostINT, err := strconv.Atoi(idPost)
 	if err != nil {
 		return nil, err
 	}
 
-	posts, err := s.storage.DeletePost(idPostINT)
+	owner, err := s.storage.GetPostOwner(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeDelete(userID, owner); err != nil {
+		return nil, err
+	}
+
+	err = s.storage.DeletePost(idPostINT, userID, moderatorDeletion(userID, owner))
 	if err != nil {
 		return nil, err
 	}
+	s.publishEvent(models.EventDelete, idPostINT, owner.Category, models.Deletion{PostID: idPostINT})
 
-	return posts, nil
+	listing, err := s.GetAllPosts(ctx, models.PageRequest{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return listing.Posts, nil
 }
 
 func (s *service) UpdateVote(ctx context.Context, idPost int, vote *models.Vote) (*models.Post, error) {
+	owner, err := s.storage.GetPostOwner(idPost)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOwnerRead(ctx, owner); err != nil {
+		return nil, err
+	}
+	if owner.Deleted {
+		return nil, ErrDeleted
+	}
+	if err := s.authorizeCommunityWrite(vote.User, owner.Category); err != nil {
+		return nil, err
+	}
+
 	post, err := s.storage.UpdateVote(idPost, vote)
 	if err != nil {
 		return nil, err
 	}
+	if vote.Vote > 0 {
+		s.notifyMilestone(owner.AuthorID, idPost, nil, post.Score)
+	}
+	s.publishEvent(models.EventScore, idPost, owner.Category, models.ScoreChange{PostID: idPost, Score: post.Score})
 
-	return post, nil
+	return s.preparePost(ctx, post, models.CommentOptions{})
 }
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrMessageBlocked возвращается, если один из собеседников заблокировал другого
+var ErrMessageBlocked = errors.New("нельзя отправить сообщение этому пользователю")
+
+const (
+	// maxMessageLength — максимальная длина личного сообщения в символах
+	maxMessageLength = 10000
+	// messageRateWindow — за какой период считается Config.MessageRateLimit
+	messageRateWindow = time.Hour
+)
+
+func (s *service) SendMessage(ctx context.Context, userID int, username string, body string) (*models.Message, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, errors.New("пустое сообщение")
+	}
+	if utf8.RuneCountInString(body) > maxMessageLength {
+		return nil, errors.New("слишком длинное сообщение")
+	}
+
+	recipientID, err := s.userID(username)
+	if err != nil {
+		return nil, err
+	}
+	if recipientID == userID {
+		return nil, errors.New("нельзя отправить сообщение самому себе")
+	}
+
+	blocked, err := s.storage.IsBlockedEither(userID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrMessageBlocked
+	}
+
+	senderName, err := s.storage.GetUserName(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &models.Message{
+		Sender:    models.User{ID: userID, Username: senderName},
+		Recipient: models.User{ID: recipientID, Username: username},
+		Body:      body,
+	}
+	if err := s.storage.SendMessage(message, s.config.MessageRateLimit, messageRateWindow); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (s *service) GetConversations(ctx context.Context, userID int, req models.PageRequest) (*models.ConversationListing, error) {
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, err := s.storage.GetConversations(userID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, next, prev := paginate(conversations, page, limit, func(c *models.Conversation) (float64, int) {
+		return c.SortKey, c.LastMessage.ID
+	})
+	if conversations == nil {
+		conversations = []*models.Conversation{}
+	}
+	return &models.ConversationListing{Conversations: conversations, Next: next, Prev: prev}, nil
+}
+
+// GetMessages возвращает переписку с пользователем username, новые сообщения первыми
+func (s *service) GetMessages(ctx context.Context, userID int, username string, req models.PageRequest) (*models.MessageListing, error) {
+	otherID, err := s.userID(username)
+	if err != nil {
+		return nil, err
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.storage.GetMessages(userID, otherID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, next, prev := paginate(messages, page, limit, func(m *models.Message) (float64, int) {
+		return m.SortKey, m.ID
+	})
+	if messages == nil {
+		messages = []*models.Message{}
+	}
+	return &models.MessageListing{Messages: messages, Next: next, Prev: prev}, nil
+}
+
+// MarkMessagesRead отмечает прочитанной переписку с пользователем username
+func (s *service) MarkMessagesRead(ctx context.Context, userID int, username string) error {
+	otherID, err := s.userID(username)
+	if err != nil {
+		return err
+	}
+	return s.storage.MarkMessagesRead(userID, otherID)
+}
+
+// DeleteMessage удаляет сообщение только у пользователя userID
+func (s *service) DeleteMessage(ctx context.Context, userID int, messageID string) error {
+	messageIDINT, err := strconv.Atoi(messageID)
+	if err != nil {
+		return err
+	}
+	return s.storage.DeleteMessage(userID, messageIDINT)
+}
+
+// userID возвращает ID пользователя username или storage.ErrUserNotFound
+func (s *service) userID(username string) (int, error) {
+	ids, err := s.storage.GetUserIDs([]string{username})
+	if err != nil {
+		return 0, err
+	}
+
+	id, ok := ids[username]
+	if !ok {
+		return 0, storage.ErrUserNotFound
+	}
+	return id, nil
+}
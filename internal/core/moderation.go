@@ -0,0 +1,271 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"strconv"
+)
+
+// ErrPostLocked возвращается при попытке прокомментировать закрытый пост
+var ErrPostLocked = errors.New("пост закрыт для новых комментариев")
+
+// ErrRemoveCreator возвращается при попытке снять создателя сообщества с модерации
+var ErrRemoveCreator = errors.New("нельзя снять создателя сообщества с модерации")
+
+// Заглушки вместо текста и автора удаленных записей
+const (
+	deletedBody = "[deleted]" // удалено автором
+	removedBody = "[removed]" // удалено модератором
+)
+
+func (s *service) ModeratePost(ctx context.Context, userID int, idPost string, action string, reason string) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.storage.GetPostOwner(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeModerator(userID, owner.Category); err != nil {
+		return nil, err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: action, Reason: reason}
+	if err := s.storage.ModeratePost(idPostINT, entry); err != nil {
+		return nil, err
+	}
+	if action == models.ModRemove {
+		s.publishEvent(models.EventDelete, idPostINT, owner.Category, models.Deletion{PostID: idPostINT})
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+func (s *service) ModerateComment(ctx context.Context, userID int, idPost string, commentID string, action string, reason string) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	commentIDINT, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.storage.GetCommentOwner(idPostINT, commentIDINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeModerator(userID, owner.Category); err != nil {
+		return nil, err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: action, Reason: reason}
+	if err := s.storage.ModerateComment(idPostINT, commentIDINT, entry); err != nil {
+		return nil, err
+	}
+	if action == models.ModRemove {
+		s.publishEvent(models.EventDelete, idPostINT, owner.Category, models.Deletion{PostID: idPostINT, CommentID: &commentIDINT})
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+func (s *service) GetModerators(ctx context.Context, category string) ([]models.User, error) {
+	if _, err := s.GetCommunity(ctx, category); err != nil {
+		return nil, err
+	}
+
+	moderators, err := s.storage.GetModerators(category)
+	if err != nil {
+		return nil, err
+	}
+	if moderators == nil {
+		moderators = []models.User{}
+	}
+	return moderators, nil
+}
+
+func (s *service) AddModerator(ctx context.Context, userID int, category string, username string) ([]models.User, error) {
+	community, err := s.storage.GetCommunity(category)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeCommunityOwner(userID, community); err != nil {
+		return nil, err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Category: category, Action: models.ModAddModerator}
+	if err := s.storage.AddModerator(username, entry); err != nil {
+		return nil, err
+	}
+	return s.GetModerators(ctx, category)
+}
+
+func (s *service) RemoveModerator(ctx context.Context, userID int, category string, username string) ([]models.User, error) {
+	community, err := s.storage.GetCommunity(category)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeCommunityOwner(userID, community); err != nil {
+		return nil, err
+	}
+	if username == community.Creator.Username {
+		return nil, ErrRemoveCreator
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Category: category, Action: models.ModRemoveModerator}
+	if err := s.storage.RemoveModerator(username, entry); err != nil {
+		return nil, err
+	}
+	return s.GetModerators(ctx, category)
+}
+
+func (s *service) GetModLog(ctx context.Context, userID int, category string, req models.PageRequest) (*models.ModLogListing, error) {
+	if err := s.authorizeModerator(userID, category); err != nil {
+		return nil, err
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := s.storage.GetModLog(category, page)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, next, prev := paginate(actions, page, limit, func(action *models.ModAction) (float64, int) {
+		return action.SortKey, action.ID
+	})
+	if actions == nil {
+		actions = []*models.ModAction{}
+	}
+	return &models.ModLogListing{Actions: actions, Next: next, Prev: prev}, nil
+}
+
+// GetModQueue возвращает посты или комментарии категории, ожидающие решения модератора
+func (s *service) GetModQueue(ctx context.Context, userID int, category string, itemType string, req models.PageRequest) (*models.ModQueueListing, error) {
+	if err := s.authorizeModerator(userID, category); err != nil {
+		return nil, err
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*models.ModQueueItem
+	switch itemType {
+	case models.ItemComment:
+		items, err = s.storage.GetModQueueComments(category, page)
+	case models.ItemPost, "":
+		items, err = s.storage.GetModQueuePosts(category, page)
+	default:
+		return nil, errors.New("неизвестный тип записей очереди")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, next, prev := paginate(items, page, limit, func(item *models.ModQueueItem) (float64, int) {
+		if item.Comment != nil {
+			return item.SortKey, item.Comment.ID
+		}
+		return item.SortKey, item.PostID
+	})
+	if items == nil {
+		items = []*models.ModQueueItem{}
+	}
+	return &models.ModQueueListing{Items: items, Next: next, Prev: prev}, nil
+}
+
+// authorizeComment проверяет, что пост открыт для комментариев.
+// Модераторы могут отвечать и в закрытых постах.
+func (s *service) authorizeComment(userID int, owner storage.Owner) error {
+	if !owner.Locked {
+		return nil
+	}
+
+	err := s.authorizeModerator(userID, owner.Category)
+	if errors.Is(err, ErrForbidden) {
+		return ErrPostLocked
+	}
+	return err
+}
+
+// viewerModerates сообщает, модерирует ли категорию пользователь, от имени которого сделан запрос
+func (s *service) viewerModerates(ctx context.Context, category string) (bool, error) {
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	err := s.authorizeModerator(principal.ID, category)
+	if errors.Is(err, ErrForbidden) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// maskRemovedComments подставляет заглушки вместо удаленных комментариев.
+// Удаленные модератором комментарии модераторы категории видят целиком, если
+// текст еще не стерт. Сами комментарии остаются, чтобы не рвать ветки.
+func (s *service) maskRemovedComments(ctx context.Context, category string, comments []models.Comment) error {
+	removed := false
+	for i := range comments {
+		removed = removed || comments[i].Removed
+	}
+
+	moderates := false
+	if removed {
+		var err error
+		if moderates, err = s.viewerModerates(ctx, category); err != nil {
+			return err
+		}
+	}
+
+	for i := range comments {
+		comment := &comments[i]
+		switch {
+		case comment.Removed && (comment.Deleted || !moderates):
+			comment.Body = removedBody
+			if !moderates {
+				comment.Author = models.User{}
+			}
+		case comment.Deleted:
+			comment.Body = deletedBody
+			comment.Author = models.User{Username: deletedBody}
+		}
+	}
+	return nil
+}
+
+// maskDeletedPost подставляет заглушки вместо стертых заголовка и текста поста
+func maskDeletedPost(post *models.Post) {
+	if !post.Deleted {
+		return
+	}
+
+	placeholder := deletedBody
+	if post.Removed {
+		placeholder = removedBody
+	} else {
+		post.Author = models.User{Username: deletedBody}
+	}
+	post.Title, post.Text, post.URL = placeholder, placeholder, ""
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
+	"regexp"
+	"strconv"
+)
+
+// mentionPattern находит упоминания u/username, не являющиеся частью слова или пути
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/])u/([\p{L}\p{N}_-]+)`)
+
+const (
+	// maxMentions — сколько упоминаний из одного комментария превращается в уведомления
+	maxMentions = 10
+	// notificationBodyLength — сколько символов комментария попадает в уведомление
+	notificationBodyLength = 200
+)
+
+// scoreMilestones — оценки, о достижении которых автор получает уведомление
+var scoreMilestones = []int{10, 50, 100, 500, 1000, 5000, 10000}
+
+// notifyComment уведомляет автора поста, автора родительского комментария и
+// упомянутых пользователей о новом комментарии. Каждый получает не больше одного
+// уведомления, автор комментария — ни одного. Уведомление с текстом комментария
+// получают только те, кто может читать сообщество поста. Комментарий уже
+// сохранен, поэтому ошибка только записывается в лог.
+func (s *service) notifyComment(postID int, owner storage.Owner, comment *models.Comment) {
+	commentID := comment.ID
+
+	var community *models.Community
+	if owner.Category != "" {
+		var err error
+		if community, err = s.storage.GetCommunity(owner.Category); err != nil {
+			s.logger.Warn("Не удалось найти сообщество поста", "ошибка", err)
+			return
+		}
+	}
+
+	notified := map[int]bool{comment.Author.ID: true}
+	var notifications []*models.Notification
+	notify := func(userID int, kind string) {
+		if userID == 0 || notified[userID] {
+			return
+		}
+		notified[userID] = true
+		if !s.canReadCommunity(userID, community) {
+			return
+		}
+		notifications = append(notifications, &models.Notification{
+			UserID:    userID,
+			Type:      kind,
+			Actor:     models.User{ID: comment.Author.ID},
+			PostID:    postID,
+			CommentID: &commentID,
+		})
+	}
+
+	if comment.ParentID != nil {
+		parent, err := s.storage.GetCommentOwner(postID, *comment.ParentID)
+		if err != nil {
+			s.logger.Warn("Не удалось найти автора родительского комментария", "ошибка", err)
+		} else {
+			notify(parent.AuthorID, models.NotifyCommentReply)
+		}
+	}
+	notify(owner.AuthorID, models.NotifyPostReply)
+
+	if mentions := parseMentions(comment.Body); len(mentions) > 0 {
+		ids, err := s.storage.GetUserIDs(mentions)
+		if err != nil {
+			s.logger.Warn("Не удалось найти упомянутых пользователей", "ошибка", err)
+		}
+		for _, username := range mentions {
+			notify(ids[username], models.NotifyMention)
+		}
+	}
+
+	if err := s.storage.CreateNotifications(notifications); err != nil {
+		s.logger.Warn("Не удалось создать уведомления о комментарии", "ошибка", err)
+	}
+}
+
+// canReadCommunity сообщает, может ли пользователь читать сообщество. Без
+// сообщества пост виден всем.
+func (s *service) canReadCommunity(userID int, community *models.Community) bool {
+	if community == nil || community.Visibility != models.VisibilityPrivate {
+		return true
+	}
+
+	err := s.authorizeCommunityMember(userID, community)
+	if err != nil && !errors.Is(err, ErrForbidden) {
+		s.logger.Warn("Не удалось проверить доступ к сообществу", "ошибка", err)
+	}
+	return err == nil
+}
+
+// truncateBody обрезает текст комментария до notificationBodyLength символов
+func truncateBody(text string) string {
+	body := []rune(text)
+	if len(body) > notificationBodyLength {
+		body = append(body[:notificationBodyLength], '…')
+	}
+	return string(body)
+}
+
+// parseMentions возвращает до maxMentions разных имен, упомянутых как u/username
+func parseMentions(text string) []string {
+	var mentions []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		mentions = append(mentions, username)
+		if len(mentions) == maxMentions {
+			break
+		}
+	}
+	return mentions
+}
+
+// notifyMilestone уведомляет автора, если оценка его поста или комментария
+// достигла одной из scoreMilestones. Об одной отметке уведомление приходит один раз.
+func (s *service) notifyMilestone(authorID int, postID int, commentID *int, score int) {
+	milestone := 0
+	for _, m := range scoreMilestones {
+		if score >= m {
+			milestone = m
+		}
+	}
+	if milestone == 0 || authorID == 0 {
+		return
+	}
+
+	kind := models.NotifyPostMilestone
+	if commentID != nil {
+		kind = models.NotifyCommentMilestone
+	}
+	notification := &models.Notification{
+		UserID:    authorID,
+		Type:      kind,
+		PostID:    postID,
+		CommentID: commentID,
+		Milestone: milestone,
+	}
+	if err := s.storage.CreateNotifications([]*models.Notification{notification}); err != nil {
+		s.logger.Warn("Не удалось создать уведомление об оценке", "ошибка", err)
+	}
+}
+
+func (s *service) GetNotifications(ctx context.Context, userID int, unreadOnly bool, req models.PageRequest) (*models.NotificationListing, error) {
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := s.storage.GetNotifications(userID, unreadOnly, page)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, next, prev := paginate(notifications, page, limit, func(n *models.Notification) (float64, int) {
+		return n.SortKey, n.ID
+	})
+	for _, n := range notifications {
+		n.Body = truncateBody(n.Body)
+	}
+	if notifications == nil {
+		notifications = []*models.Notification{}
+	}
+	return &models.NotificationListing{Notifications: notifications, Next: next, Prev: prev}, nil
+}
+
+// MarkNotificationsRead отмечает прочитанными уведомления ids, а если ids пуст — все
+func (s *service) MarkNotificationsRead(ctx context.Context, userID int, ids []int) error {
+	return s.storage.MarkNotificationsRead(userID, ids)
+}
+
+func (s *service) MarkNotificationRead(ctx context.Context, userID int, notificationID string) error {
+	id, err := strconv.Atoi(notificationID)
+	if err != nil {
+		return err
+	}
+	return s.storage.MarkNotificationsRead(userID, []int{id})
+}
+
+// CountUnread возвращает число непрочитанных уведомлений и личных сообщений
+func (s *service) CountUnread(ctx context.Context, userID int) (*models.UnreadCount, error) {
+	notifications, err := s.storage.CountUnreadNotifications(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.storage.CountUnreadMessages(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UnreadCount{
+		Count:         notifications + messages,
+		Notifications: notifications,
+		Messages:      messages,
+	}, nil
+}
+
+// commentScore возвращает оценку комментария commentID из загруженного поста
+func commentScore(post *models.Post, commentID int) (int, bool) {
+	for _, comment := range post.Comments {
+		if comment.ID == commentID {
+			return comment.Score, true
+		}
+	}
+	return 0, false
+}
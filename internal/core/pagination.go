@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reddit_v2/internal/models"
+	"time"
+)
+
+const (
+	// DefaultPageLimit — размер страницы, если клиент не указал limit
+	DefaultPageLimit = 25
+	// MaxPageLimit — максимальный размер страницы
+	MaxPageLimit = 100
+)
+
+var (
+	// ErrInvalidCursor возвращается, если курсор не удалось разобрать
+	ErrInvalidCursor = errors.New("неверный курсор")
+	// ErrInvalidSort возвращается для неизвестного режима сортировки или окна
+	ErrInvalidSort = errors.New("неизвестный режим сортировки")
+)
+
+// parsePage разбирает параметры клиента вместе с режимом сортировки
+func parsePage(req models.PageRequest, defaultSort string) (models.Page, int, error) {
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return page, 0, err
+	}
+
+	page.Sort, page.Window = req.Sort, req.Window
+	if page.Sort == "" {
+		page.Sort = defaultSort
+	}
+	if page.Window == "" {
+		page.Window = models.WindowDay
+	}
+
+	switch page.Sort {
+	case models.SortHot, models.SortNew, models.SortTop, models.SortRising, models.SortControversial:
+	default:
+		return page, 0, ErrInvalidSort
+	}
+	switch page.Window {
+	case models.WindowHour, models.WindowDay, models.WindowWeek, models.WindowMonth, models.WindowYear, models.WindowAll:
+	default:
+		return page, 0, ErrInvalidSort
+	}
+	if err := checkCursorSort(page); err != nil {
+		return page, 0, err
+	}
+
+	return page, limit, nil
+}
+
+// checkCursorSort отклоняет курсор, выданный для другого режима сортировки:
+// его ключ в новом порядке ничего не значит
+func checkCursorSort(page models.Page) error {
+	if cursor := cursorOf(page); cursor != nil && cursor.Sort != page.Sort {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// parseCursorPage разбирает размер страницы и курсор. В возвращаемой Page лимит
+// на единицу больше запрошенного, чтобы по лишней строке понять, есть ли
+// следующая страница.
+func parseCursorPage(req models.PageRequest) (models.Page, int, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	page := models.Page{Limit: limit + 1}
+
+	var err error
+	switch {
+	case req.Before != "":
+		page.Before, err = decodeCursor(req.Before)
+	case req.After != "":
+		page.After, err = decodeCursor(req.After)
+	}
+	if err != nil {
+		return page, 0, err
+	}
+
+	// Окна и возраст постов на всех страницах считаются от момента первого запроса,
+	// иначе ключи сортировки сдвигались бы между страницами
+	page.At = time.Now()
+	if cursor := cursorOf(page); cursor != nil && cursor.At != 0 {
+		page.At = time.Unix(cursor.At, 0)
+	}
+
+	return page, limit, nil
+}
+
+func cursorOf(page models.Page) *models.Cursor {
+	if page.Before != nil {
+		return page.Before
+	}
+	return page.After
+}
+
+func encodeCursor(cursor models.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*models.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor models.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// paginate отбрасывает лишнюю строку, которую хранилище вернуло сверх limit,
+// и строит курсоры соседних страниц. position возвращает ключ сортировки и ID элемента.
+func paginate[T any](items []T, page models.Page, limit int, position func(item T) (float64, int)) ([]T, string, string) {
+	hasMore := len(items) > limit
+	if hasMore {
+		if page.Before != nil {
+			// При движении назад лишняя строка — самая дальняя от курсора, она идет первой
+			items = items[1:]
+		} else {
+			items = items[:limit]
+		}
+	}
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	var next, prev string
+	if page.Before != nil || hasMore {
+		key, id := position(items[len(items)-1])
+		next = encodeCursor(models.Cursor{Key: key, ID: id, At: page.At.Unix(), Sort: page.Sort})
+	}
+	if page.After != nil || (page.Before != nil && hasMore) {
+		key, id := position(items[0])
+		prev = encodeCursor(models.Cursor{Key: key, ID: id, At: page.At.Unix(), Sort: page.Sort})
+	}
+
+	return items, next, prev
+}
+
+// listPosts загружает страницу через fetch в порядке req.Sort (или defaultSort)
+// и собирает ответ с курсорами соседних страниц. С req.All загружаются все
+// страницы подряд.
+func (s *service) listPosts(ctx context.Context, req models.PageRequest, defaultSort string, fetch func(page models.Page) ([]*models.Post, error)) (*models.PostListing, error) {
+	if req.All {
+		return s.listAllPosts(ctx, req, defaultSort, fetch)
+	}
+
+	page, limit, err := parsePage(req, defaultSort)
+	if err != nil {
+		return nil, err
+	}
+	page.Viewer = viewerID(ctx)
+
+	posts, err := fetch(page)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, next, prev := paginate(posts, page, limit, func(post *models.Post) (float64, int) {
+		return post.SortKey, post.ID
+	})
+
+	if err := s.attachVotes(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	if posts == nil {
+		posts = []*models.Post{}
+	}
+	return &models.PostListing{Posts: posts, Next: next, Prev: prev}, nil
+}
+
+// listAllPosts собирает весь список страницами по MaxPageLimit постов
+func (s *service) listAllPosts(ctx context.Context, req models.PageRequest, defaultSort string, fetch func(page models.Page) ([]*models.Post, error)) (*models.PostListing, error) {
+	req.All = false
+	req.Limit = MaxPageLimit
+	req.Before = ""
+
+	posts := []*models.Post{}
+	for {
+		listing, err := s.listPosts(ctx, req, defaultSort, fetch)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, listing.Posts...)
+		if listing.Next == "" {
+			return &models.PostListing{Posts: posts}, nil
+		}
+		req.After = listing.Next
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReportDetails — максимальная длина пояснения к жалобе
+const maxReportDetails = 500
+
+// Решения модератора по жалобе
+const (
+	ReportActionResolve = "resolve" // удалить запись
+	ReportActionDismiss = "dismiss" // оставить запись
+)
+
+func (s *service) ReportPost(ctx context.Context, userID int, idPost string, reason string, details string) (*models.Report, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.Report{ItemType: models.ItemPost, ItemID: idPostINT, PostID: idPostINT}
+	return s.createReport(ctx, userID, report, reason, details)
+}
+
+func (s *service) ReportComment(ctx context.Context, userID int, idPost string, commentID string, reason string, details string) (*models.Report, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	commentIDINT, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.Report{ItemType: models.ItemComment, ItemID: commentIDINT, PostID: idPostINT}
+	return s.createReport(ctx, userID, report, reason, details)
+}
+
+// createReport проверяет причину жалобы и сохраняет ее. Пожаловаться можно
+// только на запись, которую пользователь видит.
+func (s *service) createReport(ctx context.Context, userID int, report *models.Report, reason string, details string) (*models.Report, error) {
+	switch reason {
+	case models.ReportSpam, models.ReportHarassment, models.ReportHate, models.ReportViolence,
+		models.ReportMisinformation, models.ReportRules, models.ReportOther:
+	default:
+		return nil, fmt.Errorf("неизвестная причина жалобы %q", reason)
+	}
+
+	details = strings.TrimSpace(details)
+	if reason == models.ReportOther && details == "" {
+		return nil, errors.New("опишите причину жалобы")
+	}
+	if utf8.RuneCountInString(details) > maxReportDetails {
+		return nil, fmt.Errorf("пояснение к жалобе длиннее %d символов", maxReportDetails)
+	}
+
+	post, err := s.storage.GetPost(report.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizePostRead(ctx, post); err != nil {
+		return nil, err
+	}
+	if post.Deleted {
+		return nil, ErrDeleted
+	}
+	if report.ItemType == models.ItemComment {
+		owner, err := s.storage.GetCommentOwner(report.PostID, report.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		if owner.Deleted {
+			return nil, ErrDeleted
+		}
+	}
+
+	report.ReporterID = userID
+	report.Reason = reason
+	report.Details = details
+
+	if err := s.storage.CreateReport(report, s.config.ReportHideThreshold); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *service) GetReports(ctx context.Context, userID int, category string, req models.PageRequest) (*models.ReportListing, error) {
+	if err := s.authorizeModerator(userID, category); err != nil {
+		return nil, err
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := s.storage.GetOpenReports(category, page)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, next, prev := paginate(reports, page, limit, func(report *models.Report) (float64, int) {
+		return report.SortKey, report.ID
+	})
+	if reports == nil {
+		reports = []*models.Report{}
+	}
+	return &models.ReportListing{Reports: reports, Next: next, Prev: prev}, nil
+}
+
+// CloseReport закрывает жалобу вместе с остальными открытыми жалобами на ту же запись:
+// resolve удаляет запись, dismiss одобряет ее и возвращает, если она была скрыта по жалобам
+func (s *service) CloseReport(ctx context.Context, userID int, reportID string, action string, reason string) (*models.Report, error) {
+	reportIDINT, err := strconv.Atoi(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	var status, modAction string
+	switch action {
+	case ReportActionResolve:
+		status, modAction = models.ReportResolved, models.ModRemove
+	case ReportActionDismiss:
+		status, modAction = models.ReportDismissed, models.ModApprove
+	default:
+		return nil, fmt.Errorf("неизвестное решение по жалобе %q", action)
+	}
+
+	report, err := s.storage.GetReport(reportIDINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeModerator(userID, report.Category); err != nil {
+		return nil, err
+	}
+
+	entry := &models.ModAction{Actor: models.User{ID: userID}, Action: modAction, Reason: reason}
+	if err := s.storage.CloseReports(reportIDINT, status, entry); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetReport(reportIDINT)
+}
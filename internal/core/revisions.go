@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"strconv"
+	"strings"
+)
+
+func (s *service) EditPost(ctx context.Context, userID int, idPost string, edit *models.PostEdit) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	if edit.Title == nil && edit.URL == nil && edit.Text == nil {
+		return nil, fmt.Errorf("нет полей для изменения")
+	}
+	if edit.Title != nil && strings.TrimSpace(*edit.Title) == "" {
+		return nil, fmt.Errorf("заголовок не может быть пустым")
+	}
+
+	owner, err := s.storage.GetPostOwner(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeEdit(userID, owner); err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.UpdatePost(idPostINT, userID, edit)
+	if err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+func (s *service) EditComment(ctx context.Context, userID int, idPost string, commentID string, body string) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	commentIDINT, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("комментарий не может быть пустым")
+	}
+
+	owner, err := s.storage.GetCommentOwner(idPostINT, commentIDINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeEdit(userID, owner); err != nil {
+		return nil, err
+	}
+
+	post, err := s.storage.UpdateComment(idPostINT, commentIDINT, userID, body)
+	if err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+func (s *service) GetPostRevisions(ctx context.Context, userID int, idPost string) ([]*models.Revision, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.storage.GetPostOwner(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeModeratorView(userID, owner.Category); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetRevisions(models.ItemPost, idPostINT)
+}
+
+func (s *service) GetCommentRevisions(ctx context.Context, userID int, idPost string, commentID string) ([]*models.Revision, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	commentIDINT, err := strconv.Atoi(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.storage.GetCommentOwner(idPostINT, commentIDINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeModeratorView(userID, owner.Category); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetRevisions(models.ItemComment, commentIDINT)
+}
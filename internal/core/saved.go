@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+	"strconv"
+)
+
+func (s *service) SavePost(ctx context.Context, userID int, idPost string) (*models.Post, error) {
+	return s.updateSaved(ctx, idPost, "", true, func(postID int, itemID int) error {
+		return s.storage.SaveItem(userID, models.ItemPost, itemID, postID)
+	})
+}
+
+func (s *service) UnsavePost(ctx context.Context, userID int, idPost string) (*models.Post, error) {
+	return s.updateSaved(ctx, idPost, "", false, func(postID int, itemID int) error {
+		return s.storage.UnsaveItem(userID, models.ItemPost, itemID)
+	})
+}
+
+func (s *service) SaveComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error) {
+	return s.updateSaved(ctx, idPost, commentID, true, func(postID int, itemID int) error {
+		return s.storage.SaveItem(userID, models.ItemComment, itemID, postID)
+	})
+}
+
+func (s *service) UnsaveComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error) {
+	return s.updateSaved(ctx, idPost, commentID, false, func(postID int, itemID int) error {
+		return s.storage.UnsaveItem(userID, models.ItemComment, itemID)
+	})
+}
+
+// updateSaved проверяет, что пост (и комментарий, если commentID не пуст)
+// существует и доступен пользователю, вызывает update и возвращает пост.
+// Сохранить удаленную запись нельзя, убрать из сохраненного — можно.
+func (s *service) updateSaved(ctx context.Context, idPost string, commentID string, save bool, update func(postID int, itemID int) error) (*models.Post, error) {
+	idPostINT, err := strconv.Atoi(idPost)
+	if err != nil {
+		return nil, err
+	}
+
+	itemID := idPostINT
+	if commentID != "" {
+		if itemID, err = strconv.Atoi(commentID); err != nil {
+			return nil, err
+		}
+		owner, err := s.storage.GetCommentOwner(idPostINT, itemID)
+		if err != nil {
+			return nil, err
+		}
+		if save && owner.Deleted {
+			return nil, ErrDeleted
+		}
+	}
+
+	post, err := s.storage.GetPost(idPostINT)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizePostRead(ctx, post); err != nil {
+		return nil, err
+	}
+	if save && post.Deleted {
+		return nil, ErrDeleted
+	}
+
+	if err := update(idPostINT, itemID); err != nil {
+		return nil, err
+	}
+	return s.preparePost(ctx, post, models.CommentOptions{})
+}
+
+// GetSaved возвращает сохраненное пользователем. Список видит только он сам.
+func (s *service) GetSaved(ctx context.Context, username string, filter models.SavedFilter, req models.PageRequest) (*models.SavedListing, error) {
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok || principal.Username != username {
+		return nil, ErrForbidden
+	}
+
+	switch filter.Type {
+	case models.ItemPost, models.ItemComment, "":
+	default:
+		return nil, errors.New("неизвестный тип сохраненных записей")
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.storage.GetSaved(principal.ID, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	items, next, prev := paginate(items, page, limit, func(item *models.SavedItem) (float64, int) {
+		return item.SortKey, item.ID
+	})
+
+	var posts []*models.Post
+	for _, item := range items {
+		if item.Post != nil {
+			posts = append(posts, item.Post)
+		}
+		if item.Comment != nil {
+			item.Comment.Saved = true
+		}
+	}
+	if err := s.attachVotes(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	if items == nil {
+		items = []*models.SavedItem{}
+	}
+	return &models.SavedListing{Items: items, Next: next, Prev: prev}, nil
+}
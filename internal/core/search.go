@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reddit_v2/internal/models"
+	"strings"
+)
+
+// ErrEmptySearch возвращается, если строка поиска пуста
+var ErrEmptySearch = errors.New("пустой поисковый запрос")
+
+func (s *service) Search(ctx context.Context, q models.SearchQuery, req models.PageRequest) (*models.SearchListing, error) {
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == "" {
+		return nil, ErrEmptySearch
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+	page.Viewer = viewerID(ctx)
+
+	var results []*models.SearchResult
+	switch q.Type {
+	case models.ItemComment:
+		results, err = s.storage.SearchComments(q, page)
+	case models.ItemPost, "":
+		results, err = s.storage.SearchPosts(q, page)
+	default:
+		return nil, errors.New("неизвестный тип результатов поиска")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results, next, prev := paginate(results, page, limit, func(result *models.SearchResult) (float64, int) {
+		if result.Comment != nil {
+			return result.Rank, result.Comment.ID
+		}
+		return result.Rank, result.PostID
+	})
+
+	var posts []*models.Post
+	for _, result := range results {
+		if result.Post != nil {
+			posts = append(posts, result.Post)
+		}
+	}
+	if err := s.attachVotes(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	if results == nil {
+		results = []*models.SearchResult{}
+	}
+	return &models.SearchListing{Results: results, Next: next, Prev: prev}, nil
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+)
+
+func (s *service) GetUserProfile(ctx context.Context, username string) (*models.UserProfile, error) {
+	return s.storage.GetUserProfile(username)
+}
+
+// GetVotedPosts возвращает посты, за которые пользователь проголосовал со
+// значением vote. Свои голоса видит только сам пользователь.
+func (s *service) GetVotedPosts(ctx context.Context, username string, vote int, req models.PageRequest) (*models.PostListing, error) {
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok || principal.Username != username {
+		return nil, ErrForbidden
+	}
+
+	return s.listPosts(ctx, req, models.SortNew, func(page models.Page) ([]*models.Post, error) {
+		return s.storage.GetVotedPosts(principal.ID, vote, page)
+	})
+}
+
+// GetVotedComments возвращает комментарии, за которые пользователь проголосовал
+// со значением vote. Свои голоса видит только сам пользователь.
+func (s *service) GetVotedComments(ctx context.Context, username string, vote int, req models.PageRequest) (*models.UserCommentListing, error) {
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok || principal.Username != username {
+		return nil, ErrForbidden
+	}
+
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.storage.GetVotedComments(principal.ID, vote, page)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, next, prev := paginate(comments, page, limit, func(comment *models.UserComment) (float64, int) {
+		return comment.SortKey, comment.ID
+	})
+	for _, comment := range comments {
+		comment.MyVote = vote
+	}
+	if comments == nil {
+		comments = []*models.UserComment{}
+	}
+	return &models.UserCommentListing{Comments: comments, Next: next, Prev: prev}, nil
+}
+
+// GetUserComments возвращает историю комментариев пользователя, новые или лучшие первыми
+func (s *service) GetUserComments(ctx context.Context, username string, req models.PageRequest) (*models.UserCommentListing, error) {
+	page, limit, err := parseCursorPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Sort = req.Sort
+	switch page.Sort {
+	case "":
+		page.Sort = models.CommentSortNew
+	case models.CommentSortNew, models.CommentSortTop:
+	default:
+		return nil, ErrInvalidSort
+	}
+	if err := checkCursorSort(page); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.storage.GetCommentsByUserLogin(username, page)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, next, prev := paginate(comments, page, limit, func(comment *models.UserComment) (float64, int) {
+		return comment.SortKey, comment.ID
+	})
+	if comments == nil {
+		comments = []*models.UserComment{}
+	}
+	return &models.UserCommentListing{Comments: comments, Next: next, Prev: prev}, nil
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
+)
+
+// attachVotes заполняет голоса, процент голосов «за», голос текущего
+// пользователя и отметку сохранения для списка постов, загружая голоса одним запросом
+func (s *service) attachVotes(ctx context.Context, posts []*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIDs := make([]int, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	votes, err := s.storage.GetVotes(postIDs)
+	if err != nil {
+		return err
+	}
+
+	viewerID := 0
+	saved := map[int]bool{}
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		viewerID = principal.ID
+		if saved, err = s.storage.GetSavedPosts(viewerID, postIDs); err != nil {
+			return err
+		}
+	}
+
+	for _, post := range posts {
+		post.Votes = votes[post.ID]
+		if post.Votes == nil {
+			post.Votes = []models.Vote{}
+		}
+
+		post.Saved = saved[post.ID]
+
+		upvotes := 0
+		post.MyVote = 0
+		for _, vote := range post.Votes {
+			if vote.Vote > 0 {
+				upvotes++
+			}
+			if vote.User == viewerID {
+				post.MyVote = vote.Vote
+			}
+		}
+
+		post.UpvotePercentage = 0
+		if len(post.Votes) > 0 {
+			post.UpvotePercentage = upvotes * 100 / len(post.Votes)
+		}
+	}
+
+	return nil
+}
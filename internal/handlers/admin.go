@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type RoleDTO struct {
+	Role string `json:"role"`
+}
+
+func (h *UserHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	username := vars["USER_LOGIN"]
+
+	var roleDTO RoleDTO
+	if err := json.NewDecoder(r.Body).Decode(&roleDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	user, err := h.service.SetUserRole(r.Context(), username, roleDTO.Role)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
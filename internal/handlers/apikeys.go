@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type APIKeyDTO struct {
+	Name string `json:"name"`
+}
+
+func (h *UserHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var keyDTO APIKeyDTO
+	if err := json.NewDecoder(r.Body).Decode(&keyDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(r.Context(), userID, keyDTO.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+func (h *UserHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.service.GetAPIKeys(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Не удалось получить API-ключи", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (h *UserHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	keyID := vars["KEY_ID"]
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		http.Error(w, "Не удалось отозвать API-ключ", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reddit_v2/internal/core"
+	"reddit_v2/internal/middleware"
+	"strings"
+)
+
+// errNoCredentials означает, что в запросе нет данных для этого способа аутентификации
+// и нужно попробовать следующий
+var errNoCredentials = errors.New("учетные данные не предоставлены")
+
+// Authenticator извлекает и проверяет учетные данные одного вида
+type Authenticator interface {
+	Authenticate(r *http.Request) (*middleware.Principal, error)
+}
+
+// bearerAuthenticator читает JWT из заголовка Authorization: Bearer
+type bearerAuthenticator struct {
+	service core.Interface
+}
+
+func (a bearerAuthenticator) Authenticate(r *http.Request) (*middleware.Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, errNoCredentials
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, errors.New("неверный заголовок Authorization")
+	}
+
+	return authenticateToken(r.Context(), a.service, strings.TrimSpace(token))
+}
+
+// cookieAuthenticator читает JWT из cookie session_id, которую ставит браузерному клиенту Login
+type cookieAuthenticator struct {
+	service core.Interface
+}
+
+func (a cookieAuthenticator) Authenticate(r *http.Request) (*middleware.Principal, error) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil || cookie.Value == "" {
+		return nil, errNoCredentials
+	}
+
+	return authenticateToken(r.Context(), a.service, cookie.Value)
+}
+
+// apiKeyAuthenticator читает ключ бота из заголовка X-API-Key
+type apiKeyAuthenticator struct {
+	service core.Interface
+}
+
+func (a apiKeyAuthenticator) Authenticate(r *http.Request) (*middleware.Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		return nil, errNoCredentials
+	}
+
+	user, err := a.service.AuthenticateAPIKey(r.Context(), key)
+	if err != nil {
+		return nil, errors.New("неверный API-ключ")
+	}
+
+	return &middleware.Principal{ID: user.ID, Username: user.Username, Role: user.Role}, nil
+}
+
+func authenticateToken(ctx context.Context, service core.Interface, token string) (*middleware.Principal, error) {
+	claims, err := service.ParseToken(ctx, token)
+	if err != nil {
+		return nil, errors.New("неверный токен")
+	}
+
+	if err := service.CheckSession(ctx, claims.SID); err != nil {
+		return nil, errors.New("сессия завершена")
+	}
+
+	return &middleware.Principal{
+		ID:        claims.User.ID,
+		Username:  claims.User.Username,
+		Role:      claims.User.Role,
+		SessionID: claims.SID,
+	}, nil
+}
+
+// defaultAuthenticators задает порядок проверки: явно переданные заголовки
+// важнее cookie, которую браузер отправляет автоматически
+func defaultAuthenticators(service core.Interface) []Authenticator {
+	return []Authenticator{
+		bearerAuthenticator{service: service},
+		apiKeyAuthenticator{service: service},
+		cookieAuthenticator{service: service},
+	}
+}
+
+// authenticate перебирает способы аутентификации по порядку. Если учетные данные
+// есть, но неверны, следующие способы не проверяются.
+func (h *UserHandler) authenticate(r *http.Request) (*middleware.Principal, error) {
+	for _, authenticator := range h.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, errNoCredentials
+}
+
+func (h *UserHandler) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := h.authenticate(r)
+		if err != nil {
+			writeUnauthorized(w, err.Error())
+			return
+		}
+
+		// Заблокированный на сайте пользователь может читать и выйти из аккаунта,
+		// но не может ничего менять
+		if !banExempt(r) {
+			if err := h.service.CheckBan(r.Context(), principal.ID); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		ctx := middleware.WithPrincipal(r.Context(), principal)
+		ctx = context.WithValue(ctx, "user_ID", principal.ID)
+		if principal.SessionID != "" {
+			ctx = context.WithValue(ctx, "session_ID", principal.SessionID)
+		}
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// banExempt сообщает, что запрос ничего не меняет или завершает сессию, и
+// блокировка на сайте его не касается
+func banExempt(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return r.Method == http.MethodPost && r.URL.Path == "/api/logout"
+}
+
+// OptionalAuthMiddleware добавляет пользователя в контекст, если запрос аутентифицирован,
+// и пропускает анонимные запросы и запросы с недействительными учетными данными.
+// Нужен публичным эндпоинтам, ответ которых зависит от того, кто смотрит.
+func (h *UserHandler) OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := h.authenticate(r)
+		if err == nil {
+			ctx := middleware.WithPrincipal(r.Context(), principal)
+			ctx = context.WithValue(ctx, "user_ID", principal.ID)
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeUnauthorized отвечает 401 обычным текстом, как и остальные ошибки обработчиков
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="reddit"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
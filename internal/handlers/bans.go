@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BanDTO — параметры бана; Days 0 означает бессрочный бан
+type BanDTO struct {
+	Reason string `json:"reason"`
+	Days   int    `json:"days"`
+}
+
+// Обработчики банов обслуживают и баны на всем сайте (/api/admin/bans),
+// и баны в категории (/api/community/{CATEGORY_NAME}/bans): категория берется из пути.
+
+func (h *UserHandler) BanUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var banDTO BanDTO
+	if err := json.NewDecoder(r.Body).Decode(&banDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	ban, err := h.service.BanUser(r.Context(), userID, vars["CATEGORY_NAME"], vars["USER_LOGIN"], banDTO.Reason, banDTO.Days)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(ban)
+}
+
+func (h *UserHandler) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.UnbanUser(r.Context(), userID, vars["CATEGORY_NAME"], vars["USER_LOGIN"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) GetBans(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	bans, err := h.service.GetBans(r.Context(), userID, vars["CATEGORY_NAME"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(bans)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *UserHandler) HidePost(w http.ResponseWriter, r *http.Request) {
+	h.postHidden(w, r, h.service.HidePost)
+}
+
+func (h *UserHandler) UnhidePost(w http.ResponseWriter, r *http.Request) {
+	h.postHidden(w, r, h.service.UnhidePost)
+}
+
+// postHidden скрывает пост или возвращает его в ленты через update
+func (h *UserHandler) postHidden(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID int, idPost string) error) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := update(r.Context(), userID, vars["POST_ID"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	h.userBlocked(w, r, h.service.BlockUser)
+}
+
+func (h *UserHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	h.userBlocked(w, r, h.service.UnblockUser)
+}
+
+// userBlocked блокирует автора или снимает блокировку через update
+func (h *UserHandler) userBlocked(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID int, username string) error) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := update(r.Context(), userID, vars["USER_LOGIN"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	users, err := h.service.GetBlockedUsers(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(users)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reddit_v2/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+type CommunityDTO struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Rules       []string `json:"rules"`
+	Visibility  string   `json:"visibility"`
+}
+
+func (h *UserHandler) CreateCommunity(w http.ResponseWriter, r *http.Request) {
+	var communityDTO CommunityDTO
+	if err := json.NewDecoder(r.Body).Decode(&communityDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	community := &models.Community{
+		Name:        communityDTO.Name,
+		Description: communityDTO.Description,
+		Rules:       communityDTO.Rules,
+		Visibility:  communityDTO.Visibility,
+	}
+	if err := h.service.CreateCommunity(r.Context(), userID, community); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(community)
+}
+
+func (h *UserHandler) GetCommunities(w http.ResponseWriter, r *http.Request) {
+	communities, err := h.service.GetCommunities(r.Context())
+	if err != nil {
+		http.Error(w, "Не удалось получить сообщества", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(communities)
+}
+
+func (h *UserHandler) GetCommunity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["CATEGORY_NAME"]
+
+	community, err := h.service.GetCommunity(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(community)
+}
+
+func (h *UserHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	h.subscription(w, r, h.service.Subscribe)
+}
+
+func (h *UserHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	h.subscription(w, r, h.service.Unsubscribe)
+}
+
+func (h *UserHandler) subscription(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, userID int, name string) (*models.Community, error)) {
+	vars := mux.Vars(r)
+	name := vars["CATEGORY_NAME"]
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	community, err := action(r.Context(), userID, name)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(community)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *UserHandler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := h.service.RestorePost(r.Context(), userID, vars["POST_ID"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) RestoreComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := h.service.RestoreComment(r.Context(), userID, vars["POST_ID"], vars["COMMENT_ID"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"reddit_v2/internal/core"
+	"reddit_v2/internal/storage"
+)
+
+// errorStatus подбирает HTTP-статус для известных ошибок сервиса,
+// для остальных возвращает fallback
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, core.ErrForbidden), errors.Is(err, core.ErrPostLocked), errors.Is(err, core.ErrBanned),
+		errors.Is(err, core.ErrMessageBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrPostNotFound), errors.Is(err, storage.ErrCommentNotFound),
+		errors.Is(err, storage.ErrCommunityNotFound), errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrReportNotFound), errors.Is(err, storage.ErrBanNotFound),
+		errors.Is(err, storage.ErrMessageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrCommunityExists), errors.Is(err, storage.ErrTooManyPinned),
+		errors.Is(err, storage.ErrAlreadyReported), errors.Is(err, storage.ErrReportClosed),
+		errors.Is(err, storage.ErrNotDeleted), errors.Is(err, core.ErrRemoveCreator),
+		errors.Is(err, core.ErrDeleted):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrInvalidCursor), errors.Is(err, core.ErrInvalidSort):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrMessageRateLimited):
+		return http.StatusTooManyRequests
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reddit_v2/internal/core"
+	"reddit_v2/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// heartbeatInterval — как часто в поток пишется комментарий, чтобы прокси не закрывали соединение
+	heartbeatInterval = 15 * time.Second
+	// reconnectDelay — через сколько миллисекунд EventSource переподключается после обрыва
+	reconnectDelay = 3000
+)
+
+func (h *UserHandler) PostEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	h.streamEvents(w, r, func(ctx context.Context, lastEventID int64) (*core.EventStream, error) {
+		return h.service.SubscribePostEvents(ctx, vars["POST_ID"], lastEventID)
+	})
+}
+
+func (h *UserHandler) CommunityEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	h.streamEvents(w, r, func(ctx context.Context, lastEventID int64) (*core.EventStream, error) {
+		return h.service.SubscribeCommunityEvents(ctx, vars["CATEGORY_NAME"], lastEventID)
+	})
+}
+
+// streamEvents отдает события в формате Server-Sent Events. Клиент продолжает
+// с места обрыва, передав ID последнего события в заголовке Last-Event-ID
+// (EventSource делает это сам) или в параметре lastEventId.
+func (h *UserHandler) streamEvents(w http.ResponseWriter, r *http.Request, subscribe func(ctx context.Context, lastEventID int64) (*core.EventStream, error)) {
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, "Неверный Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	stream, err := subscribe(r.Context(), lastEventID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	defer stream.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+	if stream.Reset {
+		// Пропущено слишком много: клиент перезагружает данные и продолжает с новой позиции
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", stream.ResetPosition); err != nil {
+			return
+		}
+		lastEventID = stream.ResetPosition
+	}
+	for _, event := range stream.Backlog {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+		lastEventID = event.Position
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-stream.Events:
+			if !ok {
+				// Клиент отстал; переподключившись, он дочитает пропущенное
+				return
+			}
+			if event.Position <= lastEventID {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			lastEventID = event.Position
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func parseLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("неверный ID события")
+	}
+	return id, nil
+}
+
+func writeEvent(w http.ResponseWriter, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Position, event.Type, data)
+	return err
+}
@@ -1,26 +1,29 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"reddit_v2/internal/core"
 	"reddit_v2/internal/middleware"
 	"reddit_v2/internal/models"
+	"reddit_v2/internal/storage"
 	"strconv"
 	"time"
 
-	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
 )
 
 type UserHandler struct {
-	service core.Interface
+	service        core.Interface
+	authenticators []Authenticator
 }
 
 func NewUserHandler(service core.Interface) *UserHandler {
-	return &UserHandler{service: service}
+	return &UserHandler{
+		service:        service,
+		authenticators: defaultAuthenticators(service),
+	}
 }
 
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -32,20 +35,13 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	tokenString, err := h.service.Register(r.Context(), &newUser)
+	response, err := h.service.Register(r.Context(), &newUser)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:    "session_id",
-		Value:   tokenString,
-		Expires: time.Now().Add(12 * time.Hour),
-	}
-	http.SetCookie(w, cookie)
-
-	response := middleware.RegisterResponse{AccessToken: tokenString}
+	setSessionCookies(w, response)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
@@ -60,78 +56,107 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	tokenString, err := h.service.Login(r.Context(), &newUser)
+	response, err := h.service.Login(r.Context(), &newUser)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:    "session_id",
-		Value:   tokenString,
-		Expires: time.Now().Add(12 * time.Hour),
-	}
-	http.SetCookie(w, cookie)
-
-	response := middleware.RegisterResponse{AccessToken: tokenString}
+	setSessionCookies(w, response)
 
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *UserHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	//var allPosts []*models.Post
-	allPosts, err := h.service.GetAllPosts(r.Context())
-	/*for _, post := range h.service.GetAllPosts(r.Context()) {
-		allPosts = append(allPosts, post)
-	}*/
+func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request middleware.RefreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+	}
+
+	if request.RefreshToken == "" {
+		cookie, err := r.Cookie("refresh_token")
+		if err != nil {
+			http.Error(w, "Refresh-токен не предоставлен", http.StatusUnauthorized)
+			return
+		}
+		request.RefreshToken = cookie.Value
+	}
+
+	response, err := h.service.Refresh(r.Context(), request.RefreshToken)
 	if err != nil {
-		http.Error(w, "Не удалось отправить посты", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	setSessionCookies(w, response)
+
+	json.NewEncoder(w).Encode(response)
+}
 
-	if err := json.NewEncoder(w).Encode(allPosts); err != nil {
-		http.Error(w, "Не удалось сериализовать посты в JSON", http.StatusInternalServerError)
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := r.Context().Value("session_ID").(string)
+	if !ok {
+		// Запрос с API-ключом не привязан к сессии, завершать нечего
+		http.Error(w, "Выход доступен только для входа по токену, API-ключ отзывается отдельно", http.StatusBadRequest)
 		return
 	}
-}
 
-func (h *UserHandler) AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Logout(r.Context(), sessionID); err != nil {
+		http.Error(w, "Не удалось завершить сессию", http.StatusInternalServerError)
+		return
+	}
 
-		cookie, err := r.Cookie("session_id")
-		if err != nil || cookie == nil {
-			http.Error(w, "Токен не предоставлен", http.StatusUnauthorized)
-			return
-		}
+	http.SetCookie(w, &http.Cookie{Name: "session_id", Value: "", Path: "/", MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{Name: "refresh_token", Value: "", Path: "/api/auth/", MaxAge: -1})
 
-		tokenString := cookie.Value
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		claims := &middleware.TokenClaims{}
-		jwt_token, err := jwt.ParseWithClaims(tokenString, claims, func(jwt_token *jwt.Token) (interface{}, error) {
-			if _, ok := jwt_token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("неверный метод подписи")
-			}
-			return middleware.SecretKey, nil
-		})
+// setSessionCookies сохраняет пару токенов в cookie для браузерного клиента
+func setSessionCookies(w http.ResponseWriter, tokens *middleware.RegisterResponse) {
+	http.SetCookie(w, &http.Cookie{
+		Name:    "session_id",
+		Value:   tokens.AccessToken,
+		Path:    "/",
+		Expires: time.Now().Add(middleware.AccessTokenTTL),
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    tokens.RefreshToken,
+		Path:     "/api/auth/",
+		Expires:  time.Now().Add(middleware.RefreshTokenTTL),
+		HttpOnly: true,
+	})
+}
 
-		if err != nil || !jwt_token.Valid {
-			http.Error(w, "Неверный токен", http.StatusUnauthorized)
-			return
-		}
+// GetAllPosts отдает вошедшему пользователю ленту его подписок,
+// а с параметром feed=all и гостям — общую ленту
+func (h *UserHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
+	var listing *models.PostListing
+	var err error
 
-		if claims.EXP < time.Now().Unix() {
-			http.Error(w, "Токен истек", http.StatusUnauthorized)
-			return
-		}
+	userID, ok := r.Context().Value("user_ID").(int)
+	if ok && r.URL.Query().Get("feed") != "all" {
+		listing, err = h.service.GetHomeFeed(r.Context(), userID, pageRequest(r))
+	} else {
+		listing, err = h.service.GetAllPosts(r.Context(), pageRequest(r))
+	}
+	if err != nil {
+		http.Error(w, "Не удалось отправить посты", errorStatus(err, http.StatusInternalServerError))
+		return
+	}
 
-		ctx := context.WithValue(r.Context(), "user_ID", claims.User.ID)
-		r = r.WithContext(ctx)
+	writePostListing(w, r, listing)
+}
 
-		next.ServeHTTP(w, r)
-	})
+func (h *UserHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.service.JWKS(r.Context()))
 }
 
 func (h *UserHandler) NewPost(w http.ResponseWriter, r *http.Request) {
@@ -154,10 +179,9 @@ func (h *UserHandler) NewPost(w http.ResponseWriter, r *http.Request) {
 	}
 	newPost.Author = postAuthor
 	newPost.Created = time.Now()
-	ctx := context.Background()
 
-	if err := h.service.NewPost(ctx, newPost); err != nil {
-		http.Error(w, "Не удалось создать пост", http.StatusInternalServerError)
+	if err := h.service.NewPost(r.Context(), newPost); err != nil {
+		http.Error(w, "Не удалось создать пост: "+err.Error(), errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -169,10 +193,10 @@ func (h *UserHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r) // мапа [POST_ID:1]
 	idPost := vars["POST_ID"]
 
-	post, err := h.service.GetPost(r.Context(), idPost)
+	post, err := h.service.GetPost(r.Context(), idPost, commentOptions(r))
 
 	if err != nil {
-		http.Error(w, "ошибка на стороне сервера", 400)
+		http.Error(w, "ошибка на стороне сервера", errorStatus(err, 400))
 		return
 	}
 
@@ -181,26 +205,25 @@ func (h *UserHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) GetPostsByCategory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r) // мапа [CATEGORY_NAME:music] будет иметь ввид
+	vars := mux.Vars(r) // мапа [CATEGORY_NAME:music] будет иметь ввид
 	category := vars["CATEGORY_NAME"]
-	posts, err := h.service.GetPostsByCategory(r.Context(), category)
+	listing, err := h.service.GetPostsByCategory(r.Context(), category, pageRequest(r))
 	if err != nil {
-		http.Error(w, "ошибка на стороне сервера", 400)
+		http.Error(w, "ошибка на стороне сервера", errorStatus(err, 400))
 		return
 	}
-	json.NewEncoder(w).Encode(posts)
-
+	writePostListing(w, r, listing)
 }
 
 func (h *UserHandler) GetPostsByUserLogin(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r) // мапа [CATEGORY_NAME:music] будет иметь ввид
-	category := vars["USER_LOGIN"]
-	posts, err := h.service.GetPostsByUserLogin(r.Context(), category)
+	vars := mux.Vars(r)
+	username := vars["USER_LOGIN"]
+	listing, err := h.service.GetPostsByUserLogin(r.Context(), username, pageRequest(r))
 	if err != nil {
 		http.Error(w, "ошибка на стороне сервера", 400)
 		return
 	}
-	json.NewEncoder(w).Encode(posts)
+	writePostListing(w, r, listing)
 }
 
 type CommentDTO struct {
@@ -234,21 +257,103 @@ func (h *UserHandler) AddComment(w http.ResponseWriter, r *http.Request) {
 
 	post, err := h.service.AddComment(r.Context(), idPost, &newComment)
 	if err != nil {
-		http.Error(w, "Не удалось получить пост", http.StatusUnauthorized)
+		http.Error(w, "Не удалось получить пост", errorStatus(err, http.StatusUnauthorized))
 		return
 	}
 
 	json.NewEncoder(w).Encode(post)
 }
 
+func (h *UserHandler) ReplyComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idPost := vars["POST_ID"]
+	parentID, err := strconv.Atoi(vars["COMMENT_ID"])
+	if err != nil {
+		http.Error(w, "Неверный формат ID комментария", http.StatusBadRequest)
+		return
+	}
+
+	var newCommentDTO CommentDTO
+	if err := json.NewDecoder(r.Body).Decode(&newCommentDTO); err != nil {
+		http.Error(w, "Не удалось декодировать JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	authorID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID автора", http.StatusUnauthorized)
+		return
+	}
+
+	userName, _ := h.service.GetUserName(r.Context(), authorID)
+
+	newComment := models.Comment{
+		Body:     newCommentDTO.Body,
+		Created:  time.Now(),
+		ParentID: &parentID,
+	}
+	newComment.Author.ID = authorID
+	newComment.Author.Username = userName
+
+	post, err := h.service.AddComment(r.Context(), idPost, &newComment)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(post)
+}
+
+// GetCommentThread отдает ветку комментария; используется, чтобы догрузить
+// ответы, отмеченные moreReplies
+func (h *UserHandler) GetCommentThread(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idPost := vars["POST_ID"]
+	commentID := vars["COMMENT_ID"]
+
+	comments, err := h.service.GetCommentThread(r.Context(), idPost, commentID, commentOptions(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(comments)
+}
+
+// commentOptions читает параметры comments=tree|flat, depth и sort=old|new|top
+func commentOptions(r *http.Request) models.CommentOptions {
+	query := r.URL.Query()
+	depth, _ := strconv.Atoi(query.Get("depth"))
+	return models.CommentOptions{
+		Flat:  query.Get("comments") == "flat",
+		Depth: depth,
+		Sort:  query.Get("sort"),
+	}
+}
+
 func (h *UserHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postIDStr := vars["POST_ID"]
 	commentIDStr := vars["COMMENT_ID"]
 
-	post, err := h.service.DeleteComment(r.Context(), postIDStr, commentIDStr)
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := h.service.DeleteComment(r.Context(), userID, postIDStr, commentIDStr)
 	if err != nil {
-		http.Error(w, "Не удалось получить пост", http.StatusUnauthorized)
+		switch {
+		case errors.Is(err, core.ErrForbidden):
+			http.Error(w, "Удалять комментарий может только автор, модератор или администратор", http.StatusForbidden)
+		case errors.Is(err, storage.ErrCommentNotFound):
+			http.Error(w, "Комментарий не найден", http.StatusNotFound)
+		default:
+			http.Error(w, "Не удалось получить пост", http.StatusUnauthorized)
+		}
 		return
 	}
 
@@ -259,9 +364,22 @@ func (h *UserHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postID := vars["POST_ID"]
 
-	postsAfterDeletion, err := h.service.DeletePost(r.Context(), postID)
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	postsAfterDeletion, err := h.service.DeletePost(r.Context(), userID, postID)
 	if err != nil {
-		http.Error(w, "не удалось удалить пост", http.StatusBadRequest)
+		switch {
+		case errors.Is(err, core.ErrForbidden):
+			http.Error(w, "Удалять пост может только автор, модератор или администратор", http.StatusForbidden)
+		case errors.Is(err, storage.ErrPostNotFound):
+			http.Error(w, "Пост не найден", http.StatusNotFound)
+		default:
+			http.Error(w, "не удалось удалить пост", http.StatusBadRequest)
+		}
 		return
 	}
 
@@ -289,7 +407,7 @@ func (h *UserHandler) Upvote(w http.ResponseWriter, r *http.Request) {
 
 	post, err := h.service.UpdateVote(r.Context(), postID, &newVote)
 	if err != nil {
-		http.Error(w, "не удалось отправить голос", http.StatusBadRequest)
+		http.Error(w, "не удалось отправить голос", errorStatus(err, http.StatusBadRequest))
 		return
 	}
 	json.NewEncoder(w).Encode(post)
@@ -315,7 +433,7 @@ func (h *UserHandler) Downvote(w http.ResponseWriter, r *http.Request) {
 	}
 	post, err := h.service.UpdateVote(r.Context(), postID, &newVote)
 	if err != nil {
-		http.Error(w, "не удалось отправить голос", http.StatusBadRequest)
+		http.Error(w, "не удалось отправить голос", errorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -342,7 +460,143 @@ func (h *UserHandler) Unvote(w http.ResponseWriter, r *http.Request) {
 	}
 	post, err := h.service.UpdateVote(r.Context(), postID, &newVote)
 	if err != nil {
-		http.Error(w, "не удалось отправить голос", http.StatusBadRequest)
+		http.Error(w, "не удалось отправить голос", errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) EditPost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID := vars["POST_ID"]
+
+	var edit models.PostEdit
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := h.service.EditPost(r.Context(), userID, postID, &edit)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) EditComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID := vars["POST_ID"]
+	commentID := vars["COMMENT_ID"]
+
+	var commentDTO CommentDTO
+	if err := json.NewDecoder(r.Body).Decode(&commentDTO); err != nil {
+		http.Error(w, "Не удалось декодировать JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := h.service.EditComment(r.Context(), userID, postID, commentID, commentDTO.Body)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID := vars["POST_ID"]
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	revisions, err := h.service.GetPostRevisions(r.Context(), userID, postID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func (h *UserHandler) GetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID := vars["POST_ID"]
+	commentID := vars["COMMENT_ID"]
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	revisions, err := h.service.GetCommentRevisions(r.Context(), userID, postID, commentID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func (h *UserHandler) CommentUpvote(w http.ResponseWriter, r *http.Request) {
+	h.voteComment(w, r, 1)
+}
+
+func (h *UserHandler) CommentDownvote(w http.ResponseWriter, r *http.Request) {
+	h.voteComment(w, r, -1)
+}
+
+func (h *UserHandler) CommentUnvote(w http.ResponseWriter, r *http.Request) {
+	h.voteComment(w, r, 0)
+}
+
+func (h *UserHandler) voteComment(w http.ResponseWriter, r *http.Request, value int) {
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["POST_ID"])
+	if err != nil {
+		http.Error(w, "Неверный формат ID поста", http.StatusBadRequest)
+		return
+	}
+	commentID, err := strconv.Atoi(vars["COMMENT_ID"])
+	if err != nil {
+		http.Error(w, "Неверный формат ID комментария", http.StatusBadRequest)
+		return
+	}
+
+	authorID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID автора", http.StatusUnauthorized)
+		return
+	}
+
+	newVote := models.Vote{
+		User: authorID,
+		Vote: value,
+	}
+	post, err := h.service.UpdateCommentVote(r.Context(), postID, commentID, &newVote)
+	if err != nil {
+		http.Error(w, "не удалось отправить голос", errorStatus(err, http.StatusBadRequest))
 		return
 	}
 
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reddit_v2/internal/core"
+	"reddit_v2/internal/models"
+	"testing"
+)
+
+// fakeService отвечает на удаление заранее заданной ошибкой.
+// Вызов любого другого метода core.Interface завершится паникой.
+type fakeService struct {
+	core.Interface
+	err error
+}
+
+func (f *fakeService) DeletePost(ctx context.Context, userID int, idPost string) ([]*models.Post, error) {
+	return nil, f.err
+}
+
+func (f *fakeService) DeleteComment(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error) {
+	return nil, f.err
+}
+
+func TestDeleteForbidden(t *testing.T) {
+	h := &UserHandler{service: &fakeService{err: core.ErrForbidden}}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"пост", h.DeletePost},
+		{"комментарий", h.DeleteComment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/", nil)
+			r = r.WithContext(context.WithValue(r.Context(), "user_ID", 1))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("статус %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
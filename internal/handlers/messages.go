@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// MessageDTO — текст личного сообщения
+type MessageDTO struct {
+	Body string `json:"body"`
+}
+
+func (h *UserHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	var messageDTO MessageDTO
+	if err := json.NewDecoder(r.Body).Decode(&messageDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	message, err := h.service.SendMessage(r.Context(), userID, vars["USER_LOGIN"], messageDTO.Body)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(message)
+}
+
+func (h *UserHandler) GetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.GetConversations(r.Context(), userID, pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+func (h *UserHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.GetMessages(r.Context(), userID, vars["USER_LOGIN"], pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+func (h *UserHandler) MarkMessagesRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.MarkMessagesRead(r.Context(), userID, vars["USER_LOGIN"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DeleteMessage(r.Context(), userID, vars["MESSAGE_ID"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ModReasonDTO — необязательная причина действия модератора
+type ModReasonDTO struct {
+	Reason string `json:"reason"`
+}
+
+// modReason читает причину из тела запроса; пустое тело допустимо
+func modReason(r *http.Request) (string, error) {
+	var reasonDTO ModReasonDTO
+	err := json.NewDecoder(r.Body).Decode(&reasonDTO)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return reasonDTO.Reason, nil
+}
+
+func (h *UserHandler) ModeratePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	reason, err := modReason(r)
+	if err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	post, err := h.service.ModeratePost(r.Context(), userID, vars["POST_ID"], vars["ACTION"], reason)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) ModerateComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	reason, err := modReason(r)
+	if err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	post, err := h.service.ModerateComment(r.Context(), userID, vars["POST_ID"], vars["COMMENT_ID"], vars["ACTION"], reason)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) GetModerators(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	moderators, err := h.service.GetModerators(r.Context(), vars["CATEGORY_NAME"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(moderators)
+}
+
+func (h *UserHandler) AddModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	moderators, err := h.service.AddModerator(r.Context(), userID, vars["CATEGORY_NAME"], vars["USER_LOGIN"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(moderators)
+}
+
+func (h *UserHandler) RemoveModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	moderators, err := h.service.RemoveModerator(r.Context(), userID, vars["CATEGORY_NAME"], vars["USER_LOGIN"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(moderators)
+}
+
+func (h *UserHandler) GetModLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.GetModLog(r.Context(), userID, vars["CATEGORY_NAME"], pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+func (h *UserHandler) GetModQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	itemType := r.URL.Query().Get("type")
+	listing, err := h.service.GetModQueue(r.Context(), userID, vars["CATEGORY_NAME"], itemType, pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
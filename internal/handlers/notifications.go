@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// MarkReadDTO — уведомления, которые нужно отметить прочитанными; пустой список — все
+type MarkReadDTO struct {
+	IDs []int `json:"ids"`
+}
+
+func (h *UserHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	unreadOnly := r.URL.Query().Has("unread")
+	listing, err := h.service.GetNotifications(r.Context(), userID, unreadOnly, pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// CountUnread отдает число непрочитанных уведомлений и личных сообщений
+func (h *UserHandler) CountUnread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.service.CountUnread(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(count)
+}
+
+func (h *UserHandler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	var markReadDTO MarkReadDTO
+	err := json.NewDecoder(r.Body).Decode(&markReadDTO)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.service.MarkNotificationsRead(r.Context(), userID, markReadDTO.IDs); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.MarkNotificationRead(r.Context(), userID, vars["NOTIFICATION_ID"]); err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reddit_v2/internal/models"
+	"strconv"
+)
+
+// pageRequest читает параметры limit, after, before, sort и t. Без параметров
+// пагинации списки постов отдаются целиком.
+func pageRequest(r *http.Request) models.PageRequest {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	return models.PageRequest{
+		Limit:  limit,
+		After:  query.Get("after"),
+		Before: query.Get("before"),
+		Sort:   query.Get("sort"),
+		Window: query.Get("t"),
+		All:    !isPaginated(r),
+	}
+}
+
+// isPaginated сообщает, просил ли клиент пагинацию явно
+func isPaginated(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("limit") || query.Has("after") || query.Has("before")
+}
+
+// writePostListing отдает страницу постов в конверте с курсорами. Фронтенд
+// запрашивает списки без параметров и ждет массив всех постов, поэтому в этом
+// случае отдается массив.
+func writePostListing(w http.ResponseWriter, r *http.Request, listing *models.PostListing) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if isPaginated(r) {
+		json.NewEncoder(w).Encode(listing)
+		return
+	}
+	json.NewEncoder(w).Encode(listing.Posts)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type ReportDTO struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func (h *UserHandler) ReportPost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var reportDTO ReportDTO
+	if err := json.NewDecoder(r.Body).Decode(&reportDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := h.service.ReportPost(r.Context(), userID, vars["POST_ID"], reportDTO.Reason, reportDTO.Details)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *UserHandler) ReportComment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var reportDTO ReportDTO
+	if err := json.NewDecoder(r.Body).Decode(&reportDTO); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := h.service.ReportComment(r.Context(), userID, vars["POST_ID"], vars["COMMENT_ID"], reportDTO.Reason, reportDTO.Details)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *UserHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	listing, err := h.service.GetReports(r.Context(), userID, vars["CATEGORY_NAME"], pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+func (h *UserHandler) CloseReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	reason, err := modReason(r)
+	if err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	report, err := h.service.CloseReport(r.Context(), userID, vars["REPORT_ID"], vars["ACTION"], reason)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
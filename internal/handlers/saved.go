@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reddit_v2/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *UserHandler) SavePost(w http.ResponseWriter, r *http.Request) {
+	h.postSaved(w, r, h.service.SavePost)
+}
+
+func (h *UserHandler) UnsavePost(w http.ResponseWriter, r *http.Request) {
+	h.postSaved(w, r, h.service.UnsavePost)
+}
+
+func (h *UserHandler) SaveComment(w http.ResponseWriter, r *http.Request) {
+	h.commentSaved(w, r, h.service.SaveComment)
+}
+
+func (h *UserHandler) UnsaveComment(w http.ResponseWriter, r *http.Request) {
+	h.commentSaved(w, r, h.service.UnsaveComment)
+}
+
+// postSaved сохраняет пост или убирает его из сохраненного через update
+func (h *UserHandler) postSaved(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID int, idPost string) (*models.Post, error)) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := update(r.Context(), userID, vars["POST_ID"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+// commentSaved сохраняет комментарий или убирает его из сохраненного через update
+func (h *UserHandler) commentSaved(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID int, idPost string, commentID string) (*models.Post, error)) {
+	vars := mux.Vars(r)
+
+	userID, ok := r.Context().Value("user_ID").(int)
+	if !ok {
+		http.Error(w, "Не удалось получить ID пользователя", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := update(r.Context(), userID, vars["POST_ID"], vars["COMMENT_ID"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *UserHandler) GetSaved(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	filter := models.SavedFilter{
+		Category: r.URL.Query().Get("category"),
+		Type:     r.URL.Query().Get("type"),
+	}
+	listing, err := h.service.GetSaved(r.Context(), vars["USER_LOGIN"], filter, pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reddit_v2/internal/models"
+	"time"
+)
+
+func (h *UserHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := models.SearchQuery{
+		Query:    query.Get("q"),
+		Type:     query.Get("type"),
+		Category: query.Get("category"),
+		Author:   query.Get("author"),
+	}
+
+	var err error
+	if q.From, err = parseDate(query.Get("from")); err != nil {
+		http.Error(w, "Неверный формат даты from", http.StatusBadRequest)
+		return
+	}
+	if q.To, err = parseDate(query.Get("to")); err != nil {
+		http.Error(w, "Неверный формат даты to", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.Search(r.Context(), q, pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// parseDate принимает дату в формате 2006-01-02 или RFC 3339; пустая строка — отсутствие фильтра
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
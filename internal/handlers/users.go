@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reddit_v2/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *UserHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	profile, err := h.service.GetUserProfile(r.Context(), vars["USER_LOGIN"])
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	json.NewEncoder(w).Encode(profile)
+}
+
+func (h *UserHandler) GetUserComments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	listing, err := h.service.GetUserComments(r.Context(), vars["USER_LOGIN"], pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	json.NewEncoder(w).Encode(listing)
+}
+
+func (h *UserHandler) GetUpvoted(w http.ResponseWriter, r *http.Request) {
+	h.voted(w, r, 1)
+}
+
+func (h *UserHandler) GetDownvoted(w http.ResponseWriter, r *http.Request) {
+	h.voted(w, r, -1)
+}
+
+// voted отдает посты, за которые пользователь проголосовал со значением vote,
+// или комментарии, если указан type=comment
+func (h *UserHandler) voted(w http.ResponseWriter, r *http.Request, vote int) {
+	vars := mux.Vars(r)
+
+	switch r.URL.Query().Get("type") {
+	case models.ItemPost, "":
+	case models.ItemComment:
+		listing, err := h.service.GetVotedComments(r.Context(), vars["USER_LOGIN"], vote, pageRequest(r))
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+			return
+		}
+		json.NewEncoder(w).Encode(listing)
+		return
+	default:
+		http.Error(w, "Неизвестный тип записей", http.StatusBadRequest)
+		return
+	}
+
+	listing, err := h.service.GetVotedPosts(r.Context(), vars["USER_LOGIN"], vote, pageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+		return
+	}
+	writePostListing(w, r, listing)
+}
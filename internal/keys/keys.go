@@ -0,0 +1,228 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Key — ключ подписи или проверки JWT с идентификатором kid
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any // []byte, *rsa.PrivateKey или ed25519.PrivateKey; nil, если ключ только для проверки
+	verifyKey any // []byte, *rsa.PublicKey или ed25519.PublicKey
+}
+
+// KeySet хранит активный ключ подписи и все ключи, которыми можно проверять токены.
+// Старые ключи остаются в наборе после ротации, пока не истекут выданные ими токены.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet создает набор ключей; signing должен уметь подписывать
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	if signing == nil || signing.signKey == nil {
+		return nil, errors.New("активный ключ не содержит приватной части")
+	}
+
+	ks := &KeySet{signing: signing, keys: map[string]*Key{signing.ID: signing}}
+	for _, key := range verification {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("ключ %q указан дважды", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	return ks, nil
+}
+
+// LoadFromEnv читает ключи из переменных окружения:
+//
+//	JWT_KEYS        — список "kid:alg:источник" через запятую, где alg — HS256, RS256 или EdDSA,
+//	                  а источник — путь к файлу или env:ИМЯ_ПЕРЕМЕННОЙ
+//	JWT_SIGNING_KEY — kid ключа, которым подписываются новые токены (по умолчанию первый в списке)
+//
+// Если JWT_KEYS не задан, генерируется временный HS256-ключ, и токены
+// перестают действовать после перезапуска.
+func LoadFromEnv(logger *slog.Logger) (*KeySet, error) {
+	spec := strings.TrimSpace(os.Getenv("JWT_KEYS"))
+	if spec == "" {
+		logger.Warn("JWT_KEYS не задан, используется временный ключ подписи")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return NewKeySet(NewHMACKey("ephemeral", secret))
+	}
+
+	var loaded []*Key
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("неверный формат ключа %q, ожидается kid:alg:источник", entry)
+		}
+
+		data, err := readSource(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать ключ %q: %w", parts[0], err)
+		}
+
+		key, err := ParseKey(parts[0], parts[1], data)
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, key)
+	}
+
+	signingID := os.Getenv("JWT_SIGNING_KEY")
+	if signingID == "" {
+		signingID = loaded[0].ID
+	}
+
+	var signing *Key
+	var rest []*Key
+	for _, key := range loaded {
+		if key.ID == signingID {
+			signing = key
+			continue
+		}
+		rest = append(rest, key)
+	}
+	if signing == nil {
+		return nil, fmt.Errorf("ключ подписи %q не найден в JWT_KEYS", signingID)
+	}
+
+	logger.Info("Ключи JWT загружены", "подпись", signing.ID, "всего", len(loaded))
+	return NewKeySet(signing, rest...)
+}
+
+func readSource(source string) ([]byte, error) {
+	if name, ok := strings.CutPrefix(source, "env:"); ok {
+		value := os.Getenv(name)
+		if value == "" {
+			return nil, fmt.Errorf("переменная окружения %s пуста", name)
+		}
+		return []byte(value), nil
+	}
+	return os.ReadFile(source)
+}
+
+// ParseKey разбирает ключ алгоритма alg. Для HS256 data — сам секрет,
+// для RS256 и EdDSA — приватный или публичный ключ в PEM.
+func ParseKey(kid, alg string, data []byte) (*Key, error) {
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("секрет ключа %q короче 32 байт", kid)
+		}
+		return NewHMACKey(kid, secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			return &Key{ID: kid, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать RSA-ключ %q: %w", kid, err)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, verifyKey: public}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			edPrivate := private.(ed25519.PrivateKey)
+			return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, signKey: edPrivate, verifyKey: edPrivate.Public()}, nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать EdDSA-ключ %q: %w", kid, err)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: public}, nil
+	default:
+		return nil, fmt.Errorf("алгоритм %q ключа %q не поддерживается", alg, kid)
+	}
+}
+
+func NewHMACKey(kid string, secret []byte) *Key {
+	return &Key{ID: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// Sign подписывает claims активным ключом и проставляет заголовок kid
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Keyfunc выбирает ключ проверки по заголовку kid. Токены без kid,
+// выпущенные до появления ротации, проверяются активным ключом.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := ks.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		key, ok = ks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("неизвестный ключ %q", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("неверный метод подписи")
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWK — публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные ключи набора. Симметричные HS256-ключи не публикуются.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Alg: key.Method.Alg(),
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Alg: key.Method.Alg(),
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// Principal — аутентифицированный пользователь, от имени которого выполняется запрос
+type Principal struct {
+	ID        int
+	Username  string
+	Role      string
+	SessionID string // пустой, если запрос подписан API-ключом
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает пользователя, которого положил в контекст AuthMiddleware
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// RequireRole пропускает запрос дальше, только если роль пользователя входит в roles.
+// Должен стоять после AuthMiddleware. Роль берется из токена; при смене роли
+// сессии пользователя отзываются, поэтому устаревший токен до сюда не дойдет.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Требуется аутентификация", http.StatusUnauthorized)
+				return
+			}
+
+			if !slices.Contains(roles, principal.Role) {
+				http.Error(w, "Недостаточно прав", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
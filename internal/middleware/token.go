@@ -6,20 +6,34 @@ import (
 	"time"
 )
 
+const (
+	// AccessTokenTTL время жизни access-токена
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL время жизни refresh-токена
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type TokenClaims struct {
 	User struct {
 		Username string `json:"username"`
 		ID       int    `json:"id"`
+		Role     string `json:"role"`
 	} `json:"user"`
-	IAT int64 `json:"iat"`
-	EXP int64 `json:"exp"`
+	SID string `json:"sid"` // ID семейства сессии, к которому относится токен
+	IAT int64  `json:"iat"`
+	EXP int64  `json:"exp"`
 }
 
 type RegisterResponse struct {
-	AccessToken string `json:"token"`
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
 }
 
-func GenerateTokenClaims(user *models.User) *TokenClaims {
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func GenerateTokenClaims(user *models.User, sessionID string) *TokenClaims {
 	username := user.Username
 	userID := user.ID
 
@@ -27,12 +41,15 @@ func GenerateTokenClaims(user *models.User) *TokenClaims {
 		User: struct {
 			Username string `json:"username"`
 			ID       int    `json:"id"`
+			Role     string `json:"role"`
 		}{
 			Username: username,
 			ID:       userID,
+			Role:     user.Role,
 		},
+		SID: sessionID,
 		IAT: time.Now().Unix(),
-		EXP: time.Now().Add(time.Hour * 12).Unix(),
+		EXP: time.Now().Add(AccessTokenTTL).Unix(),
 	}
 
 	return newTokenClaims
@@ -47,5 +64,3 @@ func (c *TokenClaims) Valid() error {
 
 	return nil
 }
-
-var SecretKey = []byte("мой ключ")
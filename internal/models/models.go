@@ -1,26 +1,50 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// Роли пользователей на уровне сайта
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator" // просматривает историю правок во всех категориях
+	RoleAdmin     = "admin"
+)
 
 type User struct {
 	ID       int    `json:"id" db:"id"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Role     string `json:"role,omitempty"` // Роль на уровне сайта
+
+	Created *time.Time `json:"created,omitempty"` // Дата регистрации, заполняется только в профиле
 }
 
 type Post struct {
-	ID       int       `json:"id"`
-	Title    string    `json:"title"`    // Заголовок поста
-	URL      string    `json:"url"`      // URL поста
-	Author   User      `json:"author"`   // ID автора
-	Category string    `json:"category"` // Категория поста
-	Score    int       `json:"score"`    // Оценка поста
-	Votes    []Vote    `json:"votes"`    // Список голосов
-	Comments []Comment `json:"comments"` // Список комментариев
-	Created  time.Time `json:"created"`  // Дата создания поста
-	Views    int       `json:"views"`    // Количество просмотров
-	Type     string    `json:"type"`     // Тип поста
-	Text     string    `json:"text"`     // Текст поста
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`        // Заголовок поста
+	URL      string     `json:"url"`          // URL поста
+	Author   User       `json:"author"`       // ID автора
+	Category string     `json:"category"`     // Категория поста
+	Score    int        `json:"score"`        // Оценка поста
+	Votes    []Vote     `json:"votes"`        // Список голосов
+	Comments []Comment  `json:"comments"`     // Список комментариев
+	Created  time.Time  `json:"created"`      // Дата создания поста
+	Views    int        `json:"views"`        // Количество просмотров
+	Type     string     `json:"type"`         // Тип поста
+	Text     string     `json:"text"`         // Текст поста
+	Edited   *time.Time `json:"edited"`       // Дата последнего редактирования
+	Removed  bool       `json:"removed"`      // Удален модератором
+	Approved bool       `json:"approved"`     // Одобрен модератором
+	Locked   bool       `json:"locked"`       // Закрыт для новых комментариев
+	Pinned   bool       `json:"pinned"`       // Закреплен вверху категории
+	Deleted  bool       `json:"deleted"`      // Удален автором или модератором, текст стерт
+	Saved    bool       `json:"saved" db:"-"` // Сохранен текущим пользователем
+
+	UpvotePercentage int     `json:"upvotePercentage" db:"-"` // Доля голосов «за» в процентах
+	MyVote           int     `json:"myVote" db:"-"`           // Голос текущего пользователя: -1, 0 или 1
+	SortKey          float64 `json:"-" db:"sort_key"`         // Значение ключа сортировки для курсора
 }
 
 type Vote struct {
@@ -29,8 +53,458 @@ type Vote struct {
 }
 
 type Comment struct {
-	ID      int       `json:"id"`
-	Author  User      `json:"author"`
-	Body    string    `json:"body"`    // Текст комментария
-	Created time.Time `json:"created"` // Дата создания комментария
+	ID          int        `json:"id"`
+	Author      User       `json:"author"`
+	Body        string     `json:"body"`                         // Текст комментария
+	Created     time.Time  `json:"created"`                      // Дата создания комментария
+	Edited      *time.Time `json:"edited"`                       // Дата последнего редактирования
+	Score       int        `json:"score"`                        // Оценка комментария
+	Removed     bool       `json:"removed"`                      // Удален модератором
+	Approved    bool       `json:"approved"`                     // Одобрен модератором
+	Deleted     bool       `json:"deleted"`                      // Удален автором или модератором, текст стерт
+	MyVote      int        `json:"myVote" db:"-"`                // Голос текущего пользователя: -1, 0 или 1
+	Saved       bool       `json:"saved" db:"-"`                 // Сохранен текущим пользователем
+	Collapsed   bool       `json:"collapsed" db:"-"`             // Автор заблокирован текущим пользователем
+	ParentID    *int       `json:"parentId" db:"parent_id"`      // ID родительского комментария, nil для ответа на пост
+	Depth       int        `json:"depth"`                        // Уровень вложенности, 0 для ответа на пост
+	ReplyCount  int        `json:"replyCount" db:"reply_count"`  // Количество прямых ответов
+	Replies     []Comment  `json:"replies,omitempty" db:"-"`     // Ответы, если комментарии запрошены деревом
+	MoreReplies bool       `json:"moreReplies,omitempty" db:"-"` // Ответы не загружены из-за ограничения глубины
+}
+
+// MaxCommentDepth — максимальный уровень вложенности ответов
+const MaxCommentDepth = 10
+
+// CommentOptions задает, как вернуть комментарии к посту
+type CommentOptions struct {
+	Flat  bool   // плоский список с parentId и depth вместо дерева
+	Depth int    // сколько уровней загрузить, начиная с корня
+	Sort  string // порядок ответов одного уровня: CommentSortOld, CommentSortNew или CommentSortTop
+}
+
+// Порядок сортировки комментариев
+const (
+	CommentSortOld = "old"
+	CommentSortNew = "new"
+	CommentSortTop = "top"
+)
+
+type Session struct {
+	ID        int       `json:"id"`
+	FamilyID  string    `json:"familyId" db:"family_id"` // ID семейства ротируемых токенов
+	UserID    int       `json:"userId" db:"user_id"`     // ID владельца сессии
+	TokenHash string    `json:"-" db:"token_hash"`       // SHA-256 от refresh-токена
+	Created   time.Time `json:"created"`                 // Дата выдачи токена
+	Expires   time.Time `json:"expires"`                 // Дата истечения токена
+	Used      bool      `json:"used"`                    // Токен уже обменян на новый
+	Revoked   bool      `json:"revoked"`                 // Сессия отозвана
+}
+
+type APIKey struct {
+	ID       int        `json:"id"`
+	UserID   int        `json:"-" db:"user_id"`
+	Name     string     `json:"name"`                 // Название ключа, задает владелец
+	Key      string     `json:"key,omitempty" db:"-"` // Сам ключ, возвращается только при создании
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed" db:"last_used"` // Время последнего запроса с ключом
+}
+
+// Типы объектов, у которых хранится история правок и над которыми действуют модераторы
+const (
+	ItemPost    = "post"
+	ItemComment = "comment"
+	ItemUser    = "user"
+)
+
+// Revision — версия поста или комментария до очередной правки
+type Revision struct {
+	ID       int       `json:"id"`
+	ItemType string    `json:"itemType" db:"item_type"`
+	ItemID   int       `json:"itemId" db:"item_id"`
+	Editor   User      `json:"editor"`          // Кто внес правку
+	Title    *string   `json:"title,omitempty"` // Заголовок, только для постов
+	URL      *string   `json:"url,omitempty"`   // URL, только для постов
+	Body     string    `json:"body"`            // Текст поста или комментария
+	Created  time.Time `json:"created"`         // Когда версия была заменена
+}
+
+// PostEdit — изменяемые поля поста; nil означает «не менять»
+type PostEdit struct {
+	Title *string `json:"title"`
+	URL   *string `json:"url"`
+	Text  *string `json:"text"`
+}
+
+// Cursor — позиция в списке: значение ключа сортировки и ID последнего элемента
+type Cursor struct {
+	Key  float64 `json:"k"`
+	ID   int     `json:"i"`
+	At   int64   `json:"t,omitempty"` // момент, относительно которого считались ключи, unix-время
+	Sort string  `json:"s,omitempty"` // режим сортировки, в котором выдан курсор
+}
+
+// Режимы сортировки постов
+const (
+	SortHot           = "hot"
+	SortNew           = "new"
+	SortTop           = "top"
+	SortRising        = "rising"
+	SortControversial = "controversial"
+)
+
+// Временные окна для SortTop и SortControversial
+const (
+	WindowHour  = "hour"
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowYear  = "year"
+	WindowAll   = "all"
+)
+
+// PageRequest — параметры страницы и порядка в том виде, в котором их передал клиент
+type PageRequest struct {
+	Limit  int
+	After  string // непрозрачный курсор следующей страницы
+	Before string // непрозрачный курсор предыдущей страницы
+	Sort   string // режим сортировки, см. SortHot и другие
+	Window string // временное окно, см. WindowDay и другие
+	All    bool   // отдать список постов целиком, для клиентов без пагинации
+}
+
+// Page — разобранные параметры страницы для запроса к хранилищу
+type Page struct {
+	Limit  int
+	After  *Cursor
+	Before *Cursor
+	Sort   string
+	Window string
+	At     time.Time // момент, от которого отсчитываются окна и возраст постов
+	Viewer int       // ID пользователя, чьи скрытые посты и блокировки учитываются; 0 для анонимов
+}
+
+// PostListing — страница списка постов
+type PostListing struct {
+	Posts []*Post `json:"posts"`
+	Next  string  `json:"next,omitempty"` // курсор для after, пустой на последней странице
+	Prev  string  `json:"prev,omitempty"` // курсор для before, пустой на первой странице
+}
+
+// SearchQuery — параметры полнотекстового поиска
+type SearchQuery struct {
+	Query    string     // строка поиска в синтаксисе websearch_to_tsquery
+	Type     string     // ItemPost или ItemComment
+	Category string     // только посты этой категории
+	Author   string     // только записи этого пользователя
+	From     *time.Time // созданные не раньше
+	To       *time.Time // созданные раньше
+}
+
+// SearchResult — найденный пост или комментарий
+type SearchResult struct {
+	Type      string   `json:"type"` // ItemPost или ItemComment
+	Post      *Post    `json:"post,omitempty"`
+	Comment   *Comment `json:"comment,omitempty"`
+	PostID    int      `json:"postId"`    // пост, к которому относится результат
+	PostTitle string   `json:"postTitle"` // заголовок этого поста
+	Rank      float64  `json:"rank"`      // релевантность по ts_rank
+	Snippet   string   `json:"snippet"`   // фрагмент текста, экранированный как HTML, совпадения выделены <mark>
+}
+
+// SearchListing — страница результатов поиска
+type SearchListing struct {
+	Results []*SearchResult `json:"results"`
+	Next    string          `json:"next,omitempty"`
+	Prev    string          `json:"prev,omitempty"`
+}
+
+// Видимость сообщества
+const (
+	VisibilityPublic     = "public"     // читать и писать могут все
+	VisibilityRestricted = "restricted" // читать могут все, писать — создатель и модераторы
+	VisibilityPrivate    = "private"    // сообщество скрыто от посторонних
+)
+
+// UserProfile — публичный профиль пользователя. Карма — сумма оценок его
+// неудаленных постов и комментариев вне закрытых сообществ.
+type UserProfile struct {
+	User
+	PostKarma    int `json:"postKarma" db:"post_karma"`
+	CommentKarma int `json:"commentKarma" db:"comment_karma"`
+	PostCount    int `json:"postCount" db:"post_count"`
+	CommentCount int `json:"commentCount" db:"comment_count"`
+}
+
+// UserComment — комментарий из истории пользователя вместе с постом, к которому он оставлен
+type UserComment struct {
+	Comment
+	PostID    int     `json:"postId" db:"post_id"`
+	PostTitle string  `json:"postTitle" db:"post_title"`
+	Category  string  `json:"category"`
+	SortKey   float64 `json:"-" db:"sort_key"` // Значение ключа сортировки для курсора
+}
+
+// UserCommentListing — страница истории комментариев пользователя
+type UserCommentListing struct {
+	Comments []*UserComment `json:"comments"`
+	Next     string         `json:"next,omitempty"`
+	Prev     string         `json:"prev,omitempty"`
+}
+
+// SavedFilter — необязательные фильтры списка сохраненного
+type SavedFilter struct {
+	Category string
+	Type     string // ItemPost, ItemComment или пустая строка для всех записей
+}
+
+// SavedItem — сохраненный пост или комментарий
+type SavedItem struct {
+	ID      int          `json:"-"`
+	Type    string       `json:"type"` // ItemPost или ItemComment
+	Post    *Post        `json:"post,omitempty"`
+	Comment *UserComment `json:"comment,omitempty"`
+	Saved   time.Time    `json:"saved"` // Когда запись сохранена
+	SortKey float64      `json:"-"`
+}
+
+// SavedListing — страница сохраненного
+type SavedListing struct {
+	Items []*SavedItem `json:"items"`
+	Next  string       `json:"next,omitempty"`
+	Prev  string       `json:"prev,omitempty"`
+}
+
+// Типы уведомлений
+const (
+	NotifyPostReply        = "post_reply"        // комментарий к посту
+	NotifyCommentReply     = "comment_reply"     // ответ на комментарий
+	NotifyMention          = "mention"           // упоминание u/username в комментарии
+	NotifyPostMilestone    = "post_milestone"    // оценка поста достигла отметки
+	NotifyCommentMilestone = "comment_milestone" // оценка комментария достигла отметки
+)
+
+// Notification — уведомление пользователя
+type Notification struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-" db:"user_id"`
+	Type      string    `json:"type"`
+	Actor     User      `json:"actor"` // кто ответил или упомянул; пустой для отметок оценки
+	PostID    int       `json:"postId" db:"post_id"`
+	PostTitle string    `json:"postTitle" db:"post_title"`
+	CommentID *int      `json:"commentId" db:"comment_id"`
+	Body      string    `json:"body"`                // начало комментария
+	Milestone int       `json:"milestone,omitempty"` // достигнутая оценка
+	Read      bool      `json:"read"`
+	Created   time.Time `json:"created"`
+	SortKey   float64   `json:"-" db:"sort_key"`
+}
+
+// NotificationListing — страница уведомлений
+type NotificationListing struct {
+	Notifications []*Notification `json:"notifications"`
+	Next          string          `json:"next,omitempty"`
+	Prev          string          `json:"prev,omitempty"`
+}
+
+// Типы событий потока обновлений
+const (
+	EventComment = "comment" // новый комментарий, data — Comment
+	EventScore   = "score"   // изменилась оценка, data — ScoreChange
+	EventDelete  = "delete"  // пост или комментарий удален, data — Deletion
+)
+
+// Event — событие потока обновлений поста или категории
+type Event struct {
+	ID       int64           `json:"id"`
+	Type     string          `json:"type"`
+	PostID   int             `json:"postId" db:"post_id"`
+	Category string          `json:"category"`
+	Data     json.RawMessage `json:"data"`
+	Created  time.Time       `json:"created"`
+	Position int64           `json:"-"` // позиция в потоке, передается клиенту как ID события SSE
+}
+
+// EventFilter выбирает события одного поста или одной категории
+type EventFilter struct {
+	PostID   int
+	Category string
+}
+
+// Match сообщает, относится ли событие к выбранному посту или категории
+func (f EventFilter) Match(event *Event) bool {
+	if f.PostID != 0 {
+		return event.PostID == f.PostID
+	}
+	return event.Category == f.Category
+}
+
+// ScoreChange — новая оценка поста или комментария
+type ScoreChange struct {
+	PostID    int  `json:"postId"`
+	CommentID *int `json:"commentId,omitempty"`
+	Score     int  `json:"score"`
+}
+
+// Deletion — удаленный пост или комментарий
+type Deletion struct {
+	PostID    int  `json:"postId"`
+	CommentID *int `json:"commentId,omitempty"`
+}
+
+// UnreadCount — число непрочитанных уведомлений и личных сообщений
+type UnreadCount struct {
+	Count         int `json:"count"` // всего
+	Notifications int `json:"notifications"`
+	Messages      int `json:"messages"`
+}
+
+// Message — личное сообщение
+type Message struct {
+	ID        int       `json:"id"`
+	Sender    User      `json:"sender"`
+	Recipient User      `json:"recipient"`
+	Body      string    `json:"body"`
+	Created   time.Time `json:"created"`
+	Read      bool      `json:"read"` // прочитано получателем
+	SortKey   float64   `json:"-" db:"sort_key"`
+}
+
+// MessageListing — страница переписки с одним пользователем, новые сообщения первыми
+type MessageListing struct {
+	Messages []*Message `json:"messages"`
+	Next     string     `json:"next,omitempty"`
+	Prev     string     `json:"prev,omitempty"`
+}
+
+// Conversation — переписка с одним пользователем
+type Conversation struct {
+	With        User    `json:"with"` // собеседник
+	LastMessage Message `json:"lastMessage" db:"last_message"`
+	Unread      int     `json:"unread"` // непрочитанные сообщения от собеседника
+	SortKey     float64 `json:"-" db:"sort_key"`
+}
+
+// ConversationListing — страница переписок, последние первыми
+type ConversationListing struct {
+	Conversations []*Conversation `json:"conversations"`
+	Next          string          `json:"next,omitempty"`
+	Prev          string          `json:"prev,omitempty"`
+}
+
+// Community — сообщество, на которое ссылается Post.Category
+type Community struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Creator     User      `json:"creator"`
+	Rules       []string  `json:"rules"`
+	Visibility  string    `json:"visibility"`
+	Created     time.Time `json:"created"`
+
+	SubscriberCount int  `json:"subscriberCount" db:"subscriber_count"`
+	Subscribed      bool `json:"subscribed" db:"-"` // Подписан ли текущий пользователь
+}
+
+// Действия модераторов
+const (
+	ModRemove          = "remove"
+	ModApprove         = "approve"
+	ModLock            = "lock"
+	ModUnlock          = "unlock"
+	ModPin             = "pin"
+	ModUnpin           = "unpin"
+	ModAddModerator    = "add_moderator"
+	ModRemoveModerator = "remove_moderator"
+	ModBan             = "ban"
+	ModUnban           = "unban"
+	ModRestore         = "restore"
+)
+
+// ModAction — запись журнала модерации
+type ModAction struct {
+	ID       int       `json:"id"`
+	Actor    User      `json:"actor"` // Модератор, выполнивший действие
+	Category string    `json:"category"`
+	Action   string    `json:"action"` // ModRemove и другие
+	ItemType string    `json:"itemType" db:"item_type"`
+	ItemID   int       `json:"itemId" db:"item_id"`
+	Reason   string    `json:"reason"`
+	Created  time.Time `json:"created"`
+	SortKey  float64   `json:"-" db:"sort_key"` // Время действия в секундах для курсора
+}
+
+// ModLogListing — страница журнала модерации
+type ModLogListing struct {
+	Actions []*ModAction `json:"actions"`
+	Next    string       `json:"next,omitempty"`
+	Prev    string       `json:"prev,omitempty"`
+}
+
+// ModQueueItem — пост или комментарий, ожидающий решения модератора
+type ModQueueItem struct {
+	Type      string   `json:"type"` // ItemPost или ItemComment
+	Post      *Post    `json:"post,omitempty"`
+	Comment   *Comment `json:"comment,omitempty"`
+	PostID    int      `json:"postId"`
+	PostTitle string   `json:"postTitle"`
+	SortKey   float64  `json:"-"`
+}
+
+// ModQueueListing — страница очереди модерации
+type ModQueueListing struct {
+	Items []*ModQueueItem `json:"items"`
+	Next  string          `json:"next,omitempty"`
+	Prev  string          `json:"prev,omitempty"`
+}
+
+// Причины жалоб
+const (
+	ReportSpam           = "spam"
+	ReportHarassment     = "harassment"
+	ReportHate           = "hate"
+	ReportViolence       = "violence"
+	ReportMisinformation = "misinformation"
+	ReportRules          = "rules" // нарушение правил сообщества
+	ReportOther          = "other"
+)
+
+// Статусы жалоб
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"  // модератор удалил запись
+	ReportDismissed = "dismissed" // модератор оставил запись
+)
+
+// Report — жалоба пользователя на пост или комментарий. Автор жалобы
+// модераторам не показывается.
+type Report struct {
+	ID         int       `json:"id"`
+	ReporterID int       `json:"-" db:"reporter_id"`
+	ItemType   string    `json:"itemType" db:"item_type"` // ItemPost или ItemComment
+	ItemID     int       `json:"itemId" db:"item_id"`
+	PostID     int       `json:"postId" db:"post_id"`
+	Category   string    `json:"category"`
+	Reason     string    `json:"reason"`  // ReportSpam и другие
+	Details    string    `json:"details"` // Пояснение пользователя
+	Status     string    `json:"status"`
+	Created    time.Time `json:"created"`
+	SortKey    float64   `json:"-" db:"sort_key"`
+}
+
+// ReportListing — страница открытых жалоб
+type ReportListing struct {
+	Reports []*Report `json:"reports"`
+	Next    string    `json:"next,omitempty"`
+	Prev    string    `json:"prev,omitempty"`
+}
+
+// Ban — блокировка пользователя на всем сайте или в одной категории
+type Ban struct {
+	ID       int        `json:"id"`
+	User     User       `json:"user"`
+	Category string     `json:"category,omitempty"` // Пусто для бана на всем сайте
+	Reason   string     `json:"reason"`
+	BannedBy User       `json:"bannedBy" db:"banned_by"`
+	Created  time.Time  `json:"created"`
+	Expires  *time.Time `json:"expires"` // nil — бессрочно
 }
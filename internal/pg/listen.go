@@ -0,0 +1,40 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen подписывает отдельное подключение из пула на канал channel и вызывает
+// handle для каждого полученного уведомления. Блокируется до отмены ctx или
+// ошибки подключения; переподключаться должен вызывающий.
+func (db *DB) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		db.logger.Error("Не удалось получить подключение для LISTEN", "канал", channel, "ошибка", err)
+		return err
+	}
+	defer conn.Release()
+
+	start := time.Now()
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	logAndMetricQuery(db.logger, "Listen", "LISTEN "+channel, nil, start, err)
+	if err != nil {
+		return err
+	}
+	db.logger.Info("Подписка на уведомления базы данных", "канал", channel)
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			db.logger.Error("Подписка на уведомления прервана", "канал", channel, "ошибка", err)
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
@@ -6,15 +6,22 @@ import (
 	"net/http"
 	"reddit_v2"
 	"reddit_v2/internal/handlers"
+	"reddit_v2/internal/middleware"
+	"reddit_v2/internal/models"
 
 	"github.com/gorilla/mux"
 )
 
 const (
-	CategoryName = "CATEGORY_NAME"
-	PostID       = "POST_ID"
-	CommentID    = "COMMENT_ID"
-	UserLogin    = "USER_LOGIN"
+	CategoryName   = "CATEGORY_NAME"
+	PostID         = "POST_ID"
+	CommentID      = "COMMENT_ID"
+	UserLogin      = "USER_LOGIN"
+	KeyID          = "KEY_ID"
+	Action         = "ACTION"
+	ReportID       = "REPORT_ID"
+	NotificationID = "NOTIFICATION_ID"
+	MessageID      = "MESSAGE_ID"
 )
 
 func InitRoutes(userHandler *handlers.UserHandler) *http.ServeMux {
@@ -39,6 +46,9 @@ func InitRoutes(userHandler *handlers.UserHandler) *http.ServeMux {
 
 	})
 
+	// Публичные ключи для проверки токенов другими сервисами
+	r.HandleFunc("/.well-known/jwks.json", userHandler.JWKS)
+
 	//Обработка статических файлов
 	r.Handle("/static/", http.FileServer(http.FS(reddit_v2.StaticFiles)))
 	err := fs.WalkDir(reddit_v2.StaticFiles, "static", func(path string, d fs.DirEntry, err error) error {
@@ -80,24 +90,101 @@ func InitRoutes(userHandler *handlers.UserHandler) *http.ServeMux {
 	api := mux.NewRouter()
 	r.Handle("/api/", api)
 
+	// Публичные эндпоинты, которые учитывают пользователя, если он вошел
+	withViewer := func(handler http.HandlerFunc) http.Handler {
+		return userHandler.OptionalAuthMiddleware(handler)
+	}
+
 	api.HandleFunc("/api/register", userHandler.Register).Methods("POST")
 	api.HandleFunc("/api/login", userHandler.Login).Methods("POST")
-	api.HandleFunc("/api/posts/", userHandler.GetAllPosts).Methods("GET")
-	api.HandleFunc("/api/post/{"+PostID+"}", userHandler.GetPost).Methods("GET")
-	api.HandleFunc("/api/posts/{"+CategoryName+"}", userHandler.GetPostsByCategory).Methods("GET")
-	api.HandleFunc("/api/user/{"+UserLogin+"}", userHandler.GetPostsByUserLogin).Methods("GET")
+	api.HandleFunc("/api/auth/refresh", userHandler.Refresh).Methods("POST")
+	api.Handle("/api/posts/", withViewer(userHandler.GetAllPosts)).Methods("GET")
+	api.Handle("/api/post/{"+PostID+"}", withViewer(userHandler.GetPost)).Methods("GET")
+	api.Handle("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}", withViewer(userHandler.GetCommentThread)).Methods("GET")
+	api.Handle("/api/posts/{"+CategoryName+"}", withViewer(userHandler.GetPostsByCategory)).Methods("GET")
+	api.Handle("/api/user/{"+UserLogin+"}", withViewer(userHandler.GetPostsByUserLogin)).Methods("GET")
+	api.Handle("/api/user/{"+UserLogin+"}/posts", withViewer(userHandler.GetPostsByUserLogin)).Methods("GET")
+	api.HandleFunc("/api/user/{"+UserLogin+"}/about", userHandler.GetUserProfile).Methods("GET")
+	api.HandleFunc("/api/user/{"+UserLogin+"}/comments", userHandler.GetUserComments).Methods("GET")
+	api.Handle("/api/search", withViewer(userHandler.Search)).Methods("GET")
+	api.HandleFunc("/api/communities", userHandler.GetCommunities).Methods("GET")
+	api.Handle("/api/community/{"+CategoryName+"}", withViewer(userHandler.GetCommunity)).Methods("GET")
+	api.Handle("/api/community/{"+CategoryName+"}/moderators", withViewer(userHandler.GetModerators)).Methods("GET")
+	api.Handle("/api/post/{"+PostID+"}/events", withViewer(userHandler.PostEvents)).Methods("GET")
+	api.Handle("/api/community/{"+CategoryName+"}/events", withViewer(userHandler.CommunityEvents)).Methods("GET")
 
 	authHandler := mux.NewRouter()
 	authWithMiddlewareHandler := userHandler.AuthMiddleware(authHandler)
 	api.PathPrefix("/api/").Handler(authWithMiddlewareHandler)
 
+	authHandler.HandleFunc("/api/logout", userHandler.Logout).Methods("POST")
+	authHandler.HandleFunc("/api/apikeys", userHandler.CreateAPIKey).Methods("POST")
+	authHandler.HandleFunc("/api/apikeys", userHandler.GetAPIKeys).Methods("GET")
+	authHandler.HandleFunc("/api/apikeys/{"+KeyID+"}", userHandler.RevokeAPIKey).Methods("DELETE")
+	authHandler.HandleFunc("/api/user/{"+UserLogin+"}/upvoted", userHandler.GetUpvoted).Methods("GET")
+	authHandler.HandleFunc("/api/user/{"+UserLogin+"}/downvoted", userHandler.GetDownvoted).Methods("GET")
+	authHandler.HandleFunc("/api/user/{"+UserLogin+"}/saved", userHandler.GetSaved).Methods("GET")
+	authHandler.HandleFunc("/api/notifications", userHandler.GetNotifications).Methods("GET")
+	authHandler.HandleFunc("/api/notifications/unread", userHandler.CountUnread).Methods("GET")
+	authHandler.HandleFunc("/api/notifications/read", userHandler.MarkNotificationsRead).Methods("POST")
+	authHandler.HandleFunc("/api/notifications/{"+NotificationID+":[0-9]+}/read", userHandler.MarkNotificationRead).Methods("POST")
+	authHandler.HandleFunc("/api/messages", userHandler.GetConversations).Methods("GET")
+	authHandler.HandleFunc("/api/messages/{"+UserLogin+"}", userHandler.GetMessages).Methods("GET")
+	authHandler.HandleFunc("/api/messages/{"+UserLogin+"}", userHandler.SendMessage).Methods("POST")
+	authHandler.HandleFunc("/api/messages/{"+UserLogin+"}/read", userHandler.MarkMessagesRead).Methods("POST")
+	authHandler.HandleFunc("/api/message/{"+MessageID+":[0-9]+}", userHandler.DeleteMessage).Methods("DELETE")
+	authHandler.HandleFunc("/api/blocks", userHandler.GetBlockedUsers).Methods("GET")
+	authHandler.HandleFunc("/api/blocks/{"+UserLogin+"}", userHandler.BlockUser).Methods("PUT")
+	authHandler.HandleFunc("/api/blocks/{"+UserLogin+"}", userHandler.UnblockUser).Methods("DELETE")
+	authHandler.HandleFunc("/api/communities", userHandler.CreateCommunity).Methods("POST")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/subscribe", userHandler.Subscribe).Methods("POST")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/unsubscribe", userHandler.Unsubscribe).Methods("POST")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/moderators/{"+UserLogin+"}", userHandler.AddModerator).Methods("PUT")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/moderators/{"+UserLogin+"}", userHandler.RemoveModerator).Methods("DELETE")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/modlog", userHandler.GetModLog).Methods("GET")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/modqueue", userHandler.GetModQueue).Methods("GET")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/reports", userHandler.GetReports).Methods("GET")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/bans", userHandler.GetBans).Methods("GET")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/bans/{"+UserLogin+"}", userHandler.BanUser).Methods("PUT")
+	authHandler.HandleFunc("/api/community/{"+CategoryName+"}/bans/{"+UserLogin+"}", userHandler.UnbanUser).Methods("DELETE")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/save", userHandler.SavePost).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/unsave", userHandler.UnsavePost).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/save", userHandler.SaveComment).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/unsave", userHandler.UnsaveComment).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/hide", userHandler.HidePost).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/unhide", userHandler.UnhidePost).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/report", userHandler.ReportPost).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/report", userHandler.ReportComment).Methods("POST")
 	authHandler.HandleFunc("/api/posts", userHandler.NewPost).Methods("POST")
 	authHandler.HandleFunc("/api/post/{"+PostID+"}", userHandler.AddComment).Methods("POST")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}", userHandler.ReplyComment).Methods("POST")
 	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+"}", userHandler.DeleteComment).Methods("DELETE")
 	authHandler.HandleFunc("/api/post/{"+PostID+"}", userHandler.DeletePost).Methods("DELETE")
 	authHandler.HandleFunc("/api/post/{"+PostID+"}/upvote", userHandler.Upvote).Methods("GET")
 	authHandler.HandleFunc("/api/post/{"+PostID+"}/downvote", userHandler.Downvote).Methods("GET")
 	authHandler.HandleFunc("/api/post/{"+PostID+"}/unvote", userHandler.Unvote).Methods("GET")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/upvote", userHandler.CommentUpvote).Methods("GET")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/downvote", userHandler.CommentDownvote).Methods("GET")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/unvote", userHandler.CommentUnvote).Methods("GET")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}", userHandler.EditPost).Methods("PATCH")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+"}", userHandler.EditComment).Methods("PATCH")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/revisions", userHandler.GetPostRevisions).Methods("GET")
+	authHandler.HandleFunc("/api/post/{"+PostID+"}/{"+CommentID+"}/revisions", userHandler.GetCommentRevisions).Methods("GET")
+
+	// Действия модераторов
+	authHandler.HandleFunc("/api/mod/post/{"+PostID+"}/{"+Action+":remove|approve|lock|unlock|pin|unpin}", userHandler.ModeratePost).Methods("POST")
+	authHandler.HandleFunc("/api/mod/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/{"+Action+":remove|approve}", userHandler.ModerateComment).Methods("POST")
+	authHandler.HandleFunc("/api/mod/report/{"+ReportID+"}/{"+Action+":resolve|dismiss}", userHandler.CloseReport).Methods("POST")
+
+	// Эндпоинты администратора
+	adminHandler := authHandler.PathPrefix("/api/admin").Subrouter()
+	adminHandler.Use(middleware.RequireRole(models.RoleAdmin))
+	adminHandler.HandleFunc("/users/{"+UserLogin+"}/role", userHandler.SetUserRole).Methods("PUT")
+	adminHandler.HandleFunc("/bans", userHandler.GetBans).Methods("GET")
+	adminHandler.HandleFunc("/bans/{"+UserLogin+"}", userHandler.BanUser).Methods("PUT")
+	adminHandler.HandleFunc("/bans/{"+UserLogin+"}", userHandler.UnbanUser).Methods("DELETE")
+	adminHandler.HandleFunc("/post/{"+PostID+"}/restore", userHandler.RestorePost).Methods("POST")
+	adminHandler.HandleFunc("/post/{"+PostID+"}/{"+CommentID+":[0-9]+}/restore", userHandler.RestoreComment).Methods("POST")
 	return r
 }
 
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAPIKeyNotFound = errors.New("API-ключ не найден")
+
+func (s *RedditDB) CreateAPIKey(key *models.APIKey, keyHash string) error {
+	query := `
+        INSERT INTO ApiKeys (user_id, name, key_hash)
+        VALUES ($1, $2, $3)
+        RETURNING id, created`
+	err := s.db.QueryOne(context.Background(), key, query, key.UserID, key.Name, keyHash)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании API-ключа: %w", err)
+	}
+	return nil
+}
+
+// GetUserByAPIKey возвращает владельца действующего ключа и обновляет время его использования
+func (s *RedditDB) GetUserByAPIKey(keyHash string) (models.User, error) {
+	var user models.User
+	query := `
+        UPDATE ApiKeys k SET last_used = now()
+        FROM Users u
+        WHERE k.key_hash = $1 AND NOT k.revoked AND u.id = k.user_id
+        RETURNING u.id, u.username, u.password, u.role`
+	err := s.db.QueryOne(context.Background(), &user, query, keyHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user, ErrAPIKeyNotFound
+		}
+		return user, fmt.Errorf("ошибка при поиске API-ключа: %w", err)
+	}
+	return user, nil
+}
+
+func (s *RedditDB) GetAPIKeys(userID int) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	query := `
+        SELECT id, user_id, name, created, last_used
+        FROM ApiKeys
+        WHERE user_id = $1 AND NOT revoked
+        ORDER BY created`
+	err := s.db.QueryMany(context.Background(), &keys, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении API-ключей: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *RedditDB) RevokeAPIKey(userID int, keyID int) error {
+	tag, err := s.db.Exec(context.Background(), `UPDATE ApiKeys SET revoked = TRUE WHERE id = $1 AND user_id = $2`, keyID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве API-ключа: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
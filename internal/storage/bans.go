@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrBanNotFound = errors.New("бан не найден")
+
+// banColumns — столбцы бана, заблокированного пользователя и того, кто выдал бан
+const banColumns = `
+            b.id, COALESCE(b.category, '') AS category, b.reason, b.created, b.expires,
+            u.id AS "user.id",
+            u.username AS "user.username",
+            COALESCE(m.id, 0) AS "banned_by.id",
+            COALESCE(m.username, '') AS "banned_by.username"`
+
+// activeBan — условие действующего бана
+const activeBan = `(b.expires IS NULL OR b.expires > CURRENT_TIMESTAMP)`
+
+// BanUser блокирует пользователя на days дней (0 — бессрочно) на всем сайте
+// или в категории ban.Category и записывает действие в журнал модерации.
+// Повторный бан заменяет предыдущий.
+func (s *RedditDB) BanUser(username string, days int, ban *models.Ban, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		query := `
+            INSERT INTO Bans (user_id, category, reason, banned_by, expires)
+            SELECT id, NULLIF($2, ''), $3, NULLIF($4, 0),
+                CASE WHEN $5 > 0 THEN CURRENT_TIMESTAMP + make_interval(days => $5) END
+            FROM Users WHERE username = $1
+            ON CONFLICT (user_id, (COALESCE(category, ''))) DO UPDATE
+            SET reason = EXCLUDED.reason,
+                banned_by = EXCLUDED.banned_by,
+                created = CURRENT_TIMESTAMP,
+                expires = EXCLUDED.expires
+            RETURNING id, user_id AS "user.id", created, expires`
+		err := tx.QueryOne(ctx, ban, query, username, ban.Category, ban.Reason, ban.BannedBy.ID, days)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("ошибка при блокировке пользователя: %w", err)
+		}
+		ban.User.Username = username
+
+		entry.Category = ban.Category
+		entry.ItemType, entry.ItemID = models.ItemUser, ban.User.ID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// UnbanUser снимает бан пользователя на всем сайте или в категории и записывает действие в журнал
+func (s *RedditDB) UnbanUser(username string, category string, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var userID int
+		query := `
+            DELETE FROM Bans b
+            USING Users u
+            WHERE u.username = $1 AND b.user_id = u.id AND COALESCE(b.category, '') = $2
+            RETURNING b.user_id`
+		err := tx.QueryOne(ctx, &userID, query, username, category)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrBanNotFound
+			}
+			return fmt.Errorf("ошибка при снятии бана: %w", err)
+		}
+
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemUser, userID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// GetActiveBan возвращает действующий бан пользователя на всем сайте (category пустая)
+// или в категории. Если бана нет, возвращается nil.
+func (s *RedditDB) GetActiveBan(userID int, category string) (*models.Ban, error) {
+	var bans []*models.Ban
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM Bans b
+        JOIN Users u ON u.id = b.user_id
+        LEFT JOIN Users m ON m.id = b.banned_by
+        WHERE b.user_id = $1 AND COALESCE(b.category, '') = $2 AND %s`, banColumns, activeBan)
+	err := s.db.QueryMany(context.Background(), &bans, query, userID, category)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке бана: %w", err)
+	}
+	if len(bans) == 0 {
+		return nil, nil
+	}
+	return bans[0], nil
+}
+
+// GetBans возвращает действующие баны на всем сайте (category пустая) или в категории
+func (s *RedditDB) GetBans(category string) ([]*models.Ban, error) {
+	var bans []*models.Ban
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM Bans b
+        JOIN Users u ON u.id = b.user_id
+        LEFT JOIN Users m ON m.id = b.banned_by
+        WHERE COALESCE(b.category, '') = $1 AND %s
+        ORDER BY b.created DESC`, banColumns, activeBan)
+	err := s.db.QueryMany(context.Background(), &bans, query, category)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении банов: %w", err)
+	}
+	return bans, nil
+}
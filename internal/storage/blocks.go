@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// HidePost скрывает пост из лент пользователя. Повторное скрытие ничего не меняет.
+func (s *RedditDB) HidePost(userID int, postID int) error {
+	query := `
+        INSERT INTO HiddenPosts (user_id, post_id)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id, post_id) DO NOTHING`
+	_, err := s.db.Exec(context.Background(), query, userID, postID)
+	if err != nil {
+		return fmt.Errorf("ошибка при скрытии поста: %w", err)
+	}
+	return nil
+}
+
+func (s *RedditDB) UnhidePost(userID int, postID int) error {
+	query := `DELETE FROM HiddenPosts WHERE user_id = $1 AND post_id = $2`
+	_, err := s.db.Exec(context.Background(), query, userID, postID)
+	if err != nil {
+		return fmt.Errorf("ошибка при возврате скрытого поста: %w", err)
+	}
+	return nil
+}
+
+// BlockUser скрывает от пользователя записи автора username. Повторная блокировка ничего не меняет.
+func (s *RedditDB) BlockUser(userID int, username string) error {
+	var blockedID int
+	query := `
+        WITH target AS (
+            SELECT id FROM Users WHERE username = $2
+        ),
+        inserted AS (
+            INSERT INTO Blocks (user_id, blocked_id)
+            SELECT $1, id FROM target
+            ON CONFLICT (user_id, blocked_id) DO NOTHING
+        )
+        SELECT id FROM target`
+	err := s.db.QueryOne(context.Background(), &blockedID, query, userID, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("ошибка при блокировке автора: %w", err)
+	}
+	return nil
+}
+
+func (s *RedditDB) UnblockUser(userID int, username string) error {
+	query := `
+        DELETE FROM Blocks b
+        USING Users u
+        WHERE b.user_id = $1 AND b.blocked_id = u.id AND u.username = $2`
+	_, err := s.db.Exec(context.Background(), query, userID, username)
+	if err != nil {
+		return fmt.Errorf("ошибка при разблокировке автора: %w", err)
+	}
+	return nil
+}
+
+// GetBlockedUsers возвращает авторов, заблокированных пользователем, последние первыми
+func (s *RedditDB) GetBlockedUsers(userID int) ([]models.User, error) {
+	var users []models.User
+	query := `
+        SELECT u.id, u.username
+        FROM Blocks b
+        JOIN Users u ON u.id = b.blocked_id
+        WHERE b.user_id = $1
+        ORDER BY b.created DESC`
+	err := s.db.QueryMany(context.Background(), &users, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении заблокированных авторов: %w", err)
+	}
+	return users, nil
+}
+
+// GetCollapsedComments возвращает ID комментариев к посту, авторов которых
+// заблокировал пользователь
+func (s *RedditDB) GetCollapsedComments(postID int, userID int) (map[int]bool, error) {
+	var ids []int
+	query := `
+        SELECT c.id
+        FROM Comments c
+        JOIN Blocks b ON b.blocked_id = c.author_id
+        WHERE c.post_id = $1 AND b.user_id = $2`
+	err := s.db.QueryMany(context.Background(), &ids, query, postID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении комментариев заблокированных авторов: %w", err)
+	}
+
+	result := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
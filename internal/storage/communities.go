@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCommunityNotFound = errors.New("сообщество не найдено")
+	ErrCommunityExists   = errors.New("сообщество с таким именем уже существует")
+)
+
+// notPrivateCommunity — условие для общих лент и поиска: посты закрытых
+// сообществ в них не попадают
+const notPrivateCommunity = `NOT EXISTS (SELECT 1 FROM Communities pc WHERE pc.name = p.category AND pc.visibility = 'private')`
+
+// communityReadable — условие доступа пользователя $1 к сообществу c: закрытое
+// сообщество читают только создатель, модераторы и администраторы сайта
+const communityReadable = `
+            c.visibility <> 'private'
+            OR c.creator_id = $1
+            OR EXISTS (SELECT 1 FROM Moderators m WHERE m.user_id = $1 AND m.category = c.name)
+            OR EXISTS (SELECT 1 FROM Users a WHERE a.id = $1 AND a.role = 'admin')`
+
+// communityColumns — столбцы сообщества и его создателя
+const communityColumns = `
+            c.id, c.name, c.description, c.rules, c.visibility, c.created, c.subscriber_count,
+            COALESCE(u.id, 0) AS "creator.id",
+            COALESCE(u.username, '') AS "creator.username"`
+
+// CreateCommunity создает сообщество и назначает создателя его модератором
+func (s *RedditDB) CreateCommunity(community *models.Community) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		query := `
+            INSERT INTO Communities (name, description, creator_id, rules, visibility)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (name) DO NOTHING
+            RETURNING id, created`
+		err := tx.QueryOne(ctx, community, query,
+			community.Name,
+			community.Description,
+			community.Creator.ID,
+			community.Rules,
+			community.Visibility,
+		)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCommunityExists
+			}
+			return fmt.Errorf("ошибка при создании сообщества: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `INSERT INTO Moderators (user_id, category) VALUES ($1, $2)`, community.Creator.ID, community.Name)
+		if err != nil {
+			return fmt.Errorf("ошибка при назначении модератора сообщества: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetCommunities возвращает все сообщества, кроме закрытых
+func (s *RedditDB) GetCommunities() ([]*models.Community, error) {
+	var communities []*models.Community
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM Communities c
+        LEFT JOIN Users u ON u.id = c.creator_id
+        WHERE c.visibility <> 'private'
+        ORDER BY c.name`, communityColumns)
+	err := s.db.QueryMany(context.Background(), &communities, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сообществ: %w", err)
+	}
+	return communities, nil
+}
+
+func (s *RedditDB) GetCommunity(name string) (*models.Community, error) {
+	var community models.Community
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM Communities c
+        LEFT JOIN Users u ON u.id = c.creator_id
+        WHERE c.name = $1`, communityColumns)
+	err := s.db.QueryOne(context.Background(), &community, query, name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCommunityNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении сообщества: %w", err)
+	}
+	return &community, nil
+}
+
+// Subscribe подписывает пользователя на сообщество. Повторная подписка ничего не меняет.
+func (s *RedditDB) Subscribe(userID int, name string) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var communityID int
+		err := tx.QueryOne(ctx, &communityID, `SELECT id FROM Communities WHERE name = $1 FOR UPDATE`, name)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCommunityNotFound
+			}
+			return fmt.Errorf("ошибка при поиске сообщества: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, `
+            INSERT INTO Subscriptions (user_id, community_id) VALUES ($1, $2)
+            ON CONFLICT DO NOTHING`, userID, communityID)
+		if err != nil {
+			return fmt.Errorf("ошибка при подписке на сообщество: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE Communities SET subscriber_count = subscriber_count + 1 WHERE id = $1`, communityID)
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении числа подписчиков: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *RedditDB) Unsubscribe(userID int, name string) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var communityID int
+		err := tx.QueryOne(ctx, &communityID, `SELECT id FROM Communities WHERE name = $1 FOR UPDATE`, name)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCommunityNotFound
+			}
+			return fmt.Errorf("ошибка при поиске сообщества: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, `DELETE FROM Subscriptions WHERE user_id = $1 AND community_id = $2`, userID, communityID)
+		if err != nil {
+			return fmt.Errorf("ошибка при отписке от сообщества: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE Communities SET subscriber_count = subscriber_count - 1 WHERE id = $1`, communityID)
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении числа подписчиков: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *RedditDB) IsSubscribed(userID int, name string) (bool, error) {
+	var subscribed bool
+	query := `
+        SELECT EXISTS(
+            SELECT 1 FROM Subscriptions s
+            JOIN Communities c ON c.id = s.community_id
+            WHERE s.user_id = $1 AND c.name = $2
+        )`
+	err := s.db.QueryOne(context.Background(), &subscribed, query, userID, name)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке подписки: %w", err)
+	}
+	return subscribed, nil
+}
+
+func (s *RedditDB) HasSubscriptions(userID int) (bool, error) {
+	var exists bool
+	err := s.db.QueryOne(context.Background(), &exists, `SELECT EXISTS(SELECT 1 FROM Subscriptions WHERE user_id = $1)`, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке подписок: %w", err)
+	}
+	return exists, nil
+}
+
+// GetSubscribedPosts — лента из постов сообществ, на которые подписан пользователь.
+// Подзапрос по подпискам превращается в полусоединение, и посты каждого
+// сообщества читаются по индексу (category, ключ сортировки).
+func (s *RedditDB) GetSubscribedPosts(userID int, page models.Page) ([]*models.Post, error) {
+	where := `p.category IN (
+            SELECT c.name FROM Subscriptions s
+            JOIN Communities c ON c.id = s.community_id
+            WHERE s.user_id = $1 AND (` + communityReadable + `))`
+	posts, err := s.listPosts(context.Background(), where, []any{userID}, page)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ленты подписок: %w", err)
+	}
+	return posts, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reddit_v2/internal/models"
 	"reddit_v2/internal/pg"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,16 +14,100 @@ import (
 type Interface interface {
 	Register(user *models.User) error
 	Login(user *models.User) (models.User, error)
-	GetAllPosts() ([]*models.Post, error)
+	GetAllPosts(page models.Page) ([]*models.Post, error)
 	NewPost(post *models.Post) error
 	GetPost(post_ID int) (*models.Post, error)
-	GetPostsByCategory(category string) ([]*models.Post, error)
-	GetPostsByUserLogin(username string) ([]*models.Post, error)
+	GetPostsByCategory(category string, page models.Page) ([]*models.Post, error)
+	GetPostsByUserLogin(username string, page models.Page) ([]*models.Post, error)
 	GetUserName(authorID int) (string, error)
 	AddComment(postID int, comment *models.Comment) (*models.Post, error)
-	DeleteComment(idPost int, commentID int) (*models.Post, error)
-	DeletePost(idPost int) ([]*models.Post, error)
+	DeleteComment(idPost int, commentID int, deleterID int, entry *models.ModAction) (*models.Post, error)
+	DeletePost(idPost int, deleterID int, entry *models.ModAction) error
 	UpdateVote(idPost int, vote *models.Vote) (*models.Post, error)
+	CreateSession(session *models.Session) error
+	RotateSession(oldHash string, newSession *models.Session) (models.User, error)
+	RevokeSession(familyID string) error
+	IsSessionActive(familyID string) (bool, error)
+	CreateAPIKey(key *models.APIKey, keyHash string) error
+	GetUserByAPIKey(keyHash string) (models.User, error)
+	GetAPIKeys(userID int) ([]*models.APIKey, error)
+	RevokeAPIKey(userID int, keyID int) error
+	GetPostOwner(idPost int) (Owner, error)
+	GetCommentOwner(idPost int, commentID int) (Owner, error)
+	GetUserRole(userID int) (string, error)
+	IsModerator(userID int, category string) (bool, error)
+	SetUserRole(username string, role string) (models.User, error)
+	UpdatePost(idPost int, editorID int, edit *models.PostEdit) (*models.Post, error)
+	UpdateComment(idPost int, commentID int, editorID int, body string) (*models.Post, error)
+	GetRevisions(itemType string, itemID int) ([]*models.Revision, error)
+	GetComments(postID int, rootID int, maxDepth int) ([]models.Comment, error)
+	GetCommentDepth(postID int, commentID int) (int, error)
+	UpdateCommentVote(idPost int, commentID int, vote *models.Vote) (*models.Post, error)
+	GetCommentVotesByUser(postID int, userID int) (map[int]int, error)
+	GetVotes(postIDs []int) (map[int][]models.Vote, error)
+	SearchPosts(q models.SearchQuery, page models.Page) ([]*models.SearchResult, error)
+	SearchComments(q models.SearchQuery, page models.Page) ([]*models.SearchResult, error)
+	CreateCommunity(community *models.Community) error
+	GetCommunities() ([]*models.Community, error)
+	GetCommunity(name string) (*models.Community, error)
+	Subscribe(userID int, name string) error
+	Unsubscribe(userID int, name string) error
+	IsSubscribed(userID int, name string) (bool, error)
+	HasSubscriptions(userID int) (bool, error)
+	GetSubscribedPosts(userID int, page models.Page) ([]*models.Post, error)
+	ModeratePost(idPost int, entry *models.ModAction) error
+	ModerateComment(idPost int, commentID int, entry *models.ModAction) error
+	GetPinnedPosts(category string, viewerID int) ([]*models.Post, error)
+	AddModerator(username string, entry *models.ModAction) error
+	RemoveModerator(username string, entry *models.ModAction) error
+	GetModerators(category string) ([]models.User, error)
+	GetModLog(category string, page models.Page) ([]*models.ModAction, error)
+	GetModQueuePosts(category string, page models.Page) ([]*models.ModQueueItem, error)
+	GetModQueueComments(category string, page models.Page) ([]*models.ModQueueItem, error)
+	CreateReport(report *models.Report, hideThreshold int) error
+	GetReport(reportID int) (*models.Report, error)
+	GetOpenReports(category string, page models.Page) ([]*models.Report, error)
+	CloseReports(reportID int, status string, entry *models.ModAction) error
+	BanUser(username string, days int, ban *models.Ban, entry *models.ModAction) error
+	UnbanUser(username string, category string, entry *models.ModAction) error
+	GetActiveBan(userID int, category string) (*models.Ban, error)
+	GetBans(category string) ([]*models.Ban, error)
+	RestorePost(idPost int, entry *models.ModAction) error
+	RestoreComment(idPost int, commentID int, entry *models.ModAction) error
+	PurgeDeleted(retention time.Duration) (int64, int64, error)
+	GetUserProfile(username string) (*models.UserProfile, error)
+	GetVotedPosts(userID int, vote int, page models.Page) ([]*models.Post, error)
+	GetVotedComments(userID int, vote int, page models.Page) ([]*models.UserComment, error)
+	GetCommentsByUserLogin(username string, page models.Page) ([]*models.UserComment, error)
+	SaveItem(userID int, itemType string, itemID int, postID int) error
+	UnsaveItem(userID int, itemType string, itemID int) error
+	GetSavedPosts(userID int, postIDs []int) (map[int]bool, error)
+	GetSavedComments(postID int, userID int) (map[int]bool, error)
+	GetSaved(userID int, filter models.SavedFilter, page models.Page) ([]*models.SavedItem, error)
+	HidePost(userID int, postID int) error
+	UnhidePost(userID int, postID int) error
+	BlockUser(userID int, username string) error
+	UnblockUser(userID int, username string) error
+	GetBlockedUsers(userID int) ([]models.User, error)
+	GetCollapsedComments(postID int, userID int) (map[int]bool, error)
+	GetUserIDs(usernames []string) (map[string]int, error)
+	CreateNotifications(notifications []*models.Notification) error
+	GetNotifications(userID int, unreadOnly bool, page models.Page) ([]*models.Notification, error)
+	MarkNotificationsRead(userID int, ids []int) error
+	CountUnreadNotifications(userID int) (int, error)
+	PublishEvent(event *models.Event) error
+	GetEventHorizon() (int64, error)
+	GetNewEvents(after int64, limit int) ([]*models.Event, error)
+	GetEventsSince(after int64, filter models.EventFilter, limit int) ([]*models.Event, error)
+	PruneEvents(retention time.Duration) (int64, error)
+	ListenEvents(ctx context.Context, handle func()) error
+	SendMessage(message *models.Message, limit int, window time.Duration) error
+	IsBlockedEither(userID int, otherID int) (bool, error)
+	GetConversations(userID int, page models.Page) ([]*models.Conversation, error)
+	GetMessages(userID int, otherID int, page models.Page) ([]*models.Message, error)
+	MarkMessagesRead(userID int, otherID int) error
+	DeleteMessage(userID int, messageID int) error
+	CountUnreadMessages(userID int) (int, error)
 	Close()
 }
 
@@ -53,8 +138,8 @@ func (s *RedditDB) Register(user *models.User) error {
 		return fmt.Errorf("пользователь с именем %s уже существует", user.Username)
 	}
 
-	sql = "INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id"
-	err = s.db.QueryOne(ctx, &user.ID, sql, user.Username, user.Password)
+	sql = "INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id, role"
+	err = s.db.QueryOne(ctx, user, sql, user.Username, user.Password)
 	if err != nil {
 		return fmt.Errorf("ошибка при вставке нового пользователя: %w", err)
 	}
@@ -65,7 +150,7 @@ func (s *RedditDB) Register(user *models.User) error {
 func (s *RedditDB) Login(user *models.User) (models.User, error) {
 	var foundUser models.User
 
-	query := "SELECT id, username, password FROM users WHERE username = $1"
+	query := "SELECT id, username, password, role FROM users WHERE username = $1"
 	err := s.db.QueryOne(context.Background(), &foundUser, query, user.Username)
 
 	if err != nil {
@@ -78,18 +163,8 @@ func (s *RedditDB) Login(user *models.User) (models.User, error) {
 	return foundUser, nil
 }
 
-func (s *RedditDB) GetAllPosts() ([]*models.Post, error) {
-	var posts []*models.Post
-
-	query := `
-        SELECT
-            p.id, p.title, p.url, p.category, p.score, p.created, p.views, p.type, p.text,
-            u.id AS "author.id",
-            u.username AS "author.username"
-        FROM Posts p
-        JOIN Users u ON u.id = p.author_id`
-
-	err := s.db.QueryMany(context.Background(), &posts, query)
+func (s *RedditDB) GetAllPosts(page models.Page) ([]*models.Post, error) {
+	posts, err := s.listPosts(context.Background(), notPrivateCommunity, nil, page)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при поиске постов: %w", err)
 	}
@@ -133,7 +208,8 @@ func (s *RedditDB) GetPost(post_ID int) (*models.Post, error) {
 
 	queryPost := `
         SELECT
-            p.id, p.title, p.url, p.category, p.score, p.created, p.views, p.type, p.text,
+            p.id, p.title, p.url, COALESCE(p.category, '') AS category, p.score, p.created, p.views, p.type, p.text, p.edited,
+            p.removed, p.approved, p.locked, p.pinned, p.deleted_at IS NOT NULL AS deleted,
             u.id AS "author.id",
             u.username AS "author.username"
         FROM Posts p
@@ -147,36 +223,67 @@ func (s *RedditDB) GetPost(post_ID int) (*models.Post, error) {
 		return nil, fmt.Errorf("ошибка при получении поста: %w", err)
 	}
 
+	comments, err := s.GetComments(post_ID, 0, models.MaxCommentDepth+1)
+	if err != nil {
+		return nil, err
+	}
+	post.Comments = comments
+
+	return &post, nil
+}
+
+// GetComments возвращает комментарии поста в порядке обхода дерева в глубину.
+// Если rootID не 0, возвращается только ветка этого комментария. maxDepth
+// ограничивает число уровней, считая от корня.
+func (s *RedditDB) GetComments(postID int, rootID int, maxDepth int) ([]models.Comment, error) {
 	var comments []models.Comment
 
-	queryComments := `
+	query := `
+        WITH RECURSIVE thread AS (
+            SELECT c.id, ARRAY[c.id] AS path
+            FROM Comments c
+            WHERE c.post_id = $1
+              AND (($2 = 0 AND c.parent_id IS NULL) OR c.id = $2)
+            UNION ALL
+            SELECT c.id, t.path || c.id
+            FROM Comments c
+            JOIN thread t ON c.parent_id = t.id
+            WHERE array_length(t.path, 1) < $3
+        )
         SELECT
-            c.id, c.body, c.created,
+            c.id, c.parent_id, c.depth, c.body, c.created, c.edited, c.score, c.removed, c.approved,
+            c.deleted_at IS NOT NULL AS deleted,
+            (SELECT count(*) FROM Comments r WHERE r.parent_id = c.id) AS reply_count,
             u.id AS "author.id",
             u.username AS "author.username"
-        FROM Comments c
+        FROM thread t
+        JOIN Comments c ON c.id = t.id
         JOIN Users u ON u.id = c.author_id
-        WHERE c.post_id = $1`
-	err = s.db.QueryMany(ctx, &comments, queryComments, post_ID)
+        ORDER BY t.path`
+	err := s.db.QueryMany(context.Background(), &comments, query, postID, rootID, maxDepth)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении комментариев: %w", err)
 	}
-	post.Comments = comments
 
-	return &post, nil
+	return comments, nil
 }
 
-func (s *RedditDB) GetPostsByCategory(category string) ([]*models.Post, error) {
-	var posts []*models.Post
-	query := `
-        SELECT
-            p.id, p.title, p.url, p.category, p.score, p.created, p.views, p.type, p.text,
-            u.id AS "author.id",
-            u.username AS "author.username"
-        FROM Posts p
-        JOIN Users u ON u.id = p.author_id
-        WHERE p.category = $1`
-	err := s.db.QueryMany(context.Background(), &posts, query, category)
+// GetCommentDepth возвращает уровень вложенности комментария
+func (s *RedditDB) GetCommentDepth(postID int, commentID int) (int, error) {
+	var depth int
+	query := `SELECT depth FROM Comments WHERE id = $1 AND post_id = $2`
+	err := s.db.QueryOne(context.Background(), &depth, query, commentID, postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrCommentNotFound
+		}
+		return 0, fmt.Errorf("ошибка при поиске комментария: %w", err)
+	}
+	return depth, nil
+}
+
+func (s *RedditDB) GetPostsByCategory(category string, page models.Page) ([]*models.Post, error) {
+	posts, err := s.listPosts(context.Background(), "p.category = $1 AND NOT p.pinned", []any{category}, page)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при поиске постов: %w", err)
 	}
@@ -184,18 +291,8 @@ func (s *RedditDB) GetPostsByCategory(category string) ([]*models.Post, error) {
 	return posts, nil
 }
 
-func (s *RedditDB) GetPostsByUserLogin(username string) ([]*models.Post, error) {
-	var posts []*models.Post
-
-	query := `
-        SELECT
-            p.id, p.title, p.url, p.category, p.score, p.created, p.views, p.type, p.text,
-            u.id AS "author.id",
-            u.username AS "author.username"
-        FROM Posts p
-        JOIN Users u ON u.id = p.author_id
-        WHERE u.username = $1`
-	err := s.db.QueryMany(context.Background(), &posts, query, username)
+func (s *RedditDB) GetPostsByUserLogin(username string, page models.Page) ([]*models.Post, error) {
+	posts, err := s.listPosts(context.Background(), "u.username = $1 AND "+notPrivateCommunity, []any{username}, page)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при поиске постов по имени пользователя: %w", err)
 	}
@@ -220,8 +317,8 @@ func (s *RedditDB) AddComment(postID int, comment *models.Comment) (*models.Post
 	ctx := context.Background()
 	var commentID int
 	query := `
-        INSERT INTO Comments (author_id, post_id, username, body, created)
-        VALUES ($1, $2, $3, $4, $5)
+        INSERT INTO Comments (author_id, post_id, username, body, created, parent_id, depth)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id`
 	err := s.db.QueryOne(
 		ctx,
@@ -232,11 +329,14 @@ func (s *RedditDB) AddComment(postID int, comment *models.Comment) (*models.Post
 		comment.Author.Username,
 		comment.Body,
 		comment.Created,
+		comment.ParentID,
+		comment.Depth,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при вставке комментария: %w", err)
 	}
+	comment.ID = commentID
 
 	post, err := s.GetPost(postID)
 	if err != nil {
@@ -246,10 +346,47 @@ func (s *RedditDB) AddComment(postID int, comment *models.Comment) (*models.Post
 	return post, nil
 }
 
-func (s *RedditDB) DeleteComment(idPost int, commentID int) (*models.Post, error) {
-	_, err := s.db.Exec(context.Background(), `DELETE FROM Comments WHERE id = $1 AND post_id = $2`, commentID, idPost)
+// DeleteComment мягко удаляет комментарий: текущая версия сохраняется в Revisions,
+// текст стирается, а ответы остаются на месте. entry задается, если комментарий
+// удаляет модератор: тогда он помечается removed и действие пишется в журнал.
+func (s *RedditDB) DeleteComment(idPost int, commentID int, deleterID int, entry *models.ModAction) (*models.Post, error) {
+	ctx := context.Background()
+
+	err := s.db.WithTx(ctx, func(tx pg.Tx) error {
+		saveRevision := `
+            INSERT INTO Revisions (item_type, item_id, editor_id, body)
+            SELECT $1, id, $4, body
+            FROM Comments
+            WHERE id = $2 AND post_id = $3 AND deleted_at IS NULL`
+		tag, err := tx.Exec(ctx, saveRevision, models.ItemComment, commentID, idPost, deleterID)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении версии комментария: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrCommentNotFound
+		}
+
+		var category string
+		deleteComment := `
+            UPDATE Comments c
+            SET body = '', deleted_at = CURRENT_TIMESTAMP, removed = c.removed OR $2
+            FROM Posts p
+            WHERE c.id = $1 AND p.id = c.post_id
+            RETURNING COALESCE(p.category, '')`
+		err = tx.QueryOne(ctx, &category, deleteComment, commentID, entry != nil)
+		if err != nil {
+			return fmt.Errorf("ошибка при удалении комментария: %w", err)
+		}
+
+		if entry == nil {
+			return nil
+		}
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemComment, commentID
+		return logModAction(ctx, tx, entry)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при удалении комментария: %w", err)
+		return nil, err
 	}
 
 	post, err := s.GetPost(idPost)
@@ -259,17 +396,45 @@ func (s *RedditDB) DeleteComment(idPost int, commentID int) (*models.Post, error
 	return post, nil
 }
 
-func (s *RedditDB) DeletePost(idPost int) ([]*models.Post, error) {
-	_, err := s.db.Exec(context.Background(), `DELETE FROM Posts WHERE id = $1`, idPost)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при удалении поста: %w", err)
-	}
+// DeletePost мягко удаляет пост: текущая версия сохраняется в Revisions, заголовок
+// и текст стираются, а комментарии и голоса остаются. entry задается, если пост
+// удаляет модератор: тогда он помечается removed и действие пишется в журнал.
+func (s *RedditDB) DeletePost(idPost int, deleterID int, entry *models.ModAction) error {
+	ctx := context.Background()
 
-	posts, err := s.GetAllPosts()
-	if err != nil {
-		return nil, err
-	}
-	return posts, nil
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		saveRevision := `
+            INSERT INTO Revisions (item_type, item_id, editor_id, title, url, body)
+            SELECT $1, id, $3, title, url, COALESCE(text, '')
+            FROM Posts
+            WHERE id = $2 AND deleted_at IS NULL`
+		tag, err := tx.Exec(ctx, saveRevision, models.ItemPost, idPost, deleterID)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении версии поста: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrPostNotFound
+		}
+
+		var category string
+		deletePost := `
+            UPDATE Posts
+            SET title = '', url = '', text = '', pinned = FALSE,
+                deleted_at = CURRENT_TIMESTAMP, removed = removed OR $2
+            WHERE id = $1
+            RETURNING COALESCE(category, '')`
+		err = tx.QueryOne(ctx, &category, deletePost, idPost, entry != nil)
+		if err != nil {
+			return fmt.Errorf("ошибка при удалении поста: %w", err)
+		}
+
+		if entry == nil {
+			return nil
+		}
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemPost, idPost
+		return logModAction(ctx, tx, entry)
+	})
 }
 
 func (s *RedditDB) UpdateVote(idPost int, vote *models.Vote) (*models.Post, error) {
@@ -284,43 +449,7 @@ func (s *RedditDB) UpdateVote(idPost int, vote *models.Vote) (*models.Post, erro
 		return nil, fmt.Errorf("пост с ID %d не найден", idPost)
 	}
 
-	err = s.db.WithTx(ctx, func(tx pg.Tx) error {
-		var existingVote int
-		var voteChange int
-
-		queryCheck := `SELECT COALESCE(vote, 0) FROM Votes WHERE user_id = $1 AND post_id = $2`
-		err := tx.QueryOne(ctx, &existingVote, queryCheck, vote.User, idPost)
-
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("ошибка при поиске голоса: %w", err)
-		}
-
-		if existingVote == vote.Vote {
-			return nil
-		}
-
-		upsertVoteQuery := `
-            INSERT INTO Votes (user_id, post_id, vote)
-            VALUES ($1, $2, $3)
-            ON CONFLICT (user_id, post_id) DO UPDATE SET vote = EXCLUDED.vote`
-		_, err = tx.Exec(ctx, upsertVoteQuery, vote.User, idPost, vote.Vote)
-		if err != nil {
-			return fmt.Errorf("ошибка при обновлении/вставке голоса: %w", err)
-		}
-
-		voteChange = vote.Vote - existingVote
-
-		if voteChange != 0 {
-			postUpdate := `UPDATE Posts SET score = score + $1 WHERE id = $2`
-			_, err = tx.Exec(ctx, postUpdate, voteChange, idPost)
-			if err != nil {
-				return fmt.Errorf("ошибка при обновлении score поста: %w", err)
-			}
-		}
-
-		return nil
-	})
-
+	err = s.applyVote(ctx, postVotes, idPost, vote)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotDeleted возвращается при попытке восстановить запись, которая не удалена
+// или уже очищена
+var ErrNotDeleted = errors.New("запись не удалена или уже очищена")
+
+// RestorePost возвращает удаленному посту последнюю сохраненную версию и записывает действие в журнал.
+// Пометка removed не снимается: снятый модератором пост одобряют отдельно.
+func (s *RedditDB) RestorePost(idPost int, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var category string
+		query := `
+            UPDATE Posts p
+            SET title = r.title, url = r.url, text = r.body, deleted_at = NULL
+            FROM (
+                SELECT title, url, body FROM Revisions
+                WHERE item_type = 'post' AND item_id = $1
+                ORDER BY created DESC, id DESC
+                LIMIT 1
+            ) r
+            WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+            RETURNING COALESCE(p.category, '')`
+		err := tx.QueryOne(ctx, &category, query, idPost)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotDeleted
+			}
+			return fmt.Errorf("ошибка при восстановлении поста: %w", err)
+		}
+
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemPost, idPost
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// RestoreComment возвращает удаленному комментарию последнюю сохраненную версию и записывает действие в журнал.
+// Пометка removed не снимается: снятый модератором комментарий одобряют отдельно.
+func (s *RedditDB) RestoreComment(idPost int, commentID int, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var category string
+		query := `
+            UPDATE Comments c
+            SET body = r.body, deleted_at = NULL
+            FROM (
+                SELECT body FROM Revisions
+                WHERE item_type = 'comment' AND item_id = $1
+                ORDER BY created DESC, id DESC
+                LIMIT 1
+            ) r, Posts p
+            WHERE c.id = $1 AND c.post_id = $2 AND c.deleted_at IS NOT NULL AND p.id = c.post_id
+            RETURNING COALESCE(p.category, '')`
+		err := tx.QueryOne(ctx, &category, query, commentID, idPost)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotDeleted
+			}
+			return fmt.Errorf("ошибка при восстановлении комментария: %w", err)
+		}
+
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemComment, commentID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// PurgeDeleted окончательно удаляет посты и комментарии, удаленные раньше чем
+// retention назад, вместе с их сохраненными версиями и жалобами. Комментарий
+// с ответами остается заглушкой, пока не будут очищены все ответы.
+// Закладки на очищенные записи тоже удаляются.
+// Возвращает число очищенных постов и комментариев.
+func (s *RedditDB) PurgeDeleted(retention time.Duration) (int64, int64, error) {
+	ctx := context.Background()
+	var posts, comments int64
+
+	err := s.db.WithTx(ctx, func(tx pg.Tx) error {
+		const expired = `deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1)`
+		seconds := retention.Seconds()
+
+		// Версии и жалобы на комментарии не связаны внешним ключом, поэтому
+		// удаляются отдельно: сначала у комментариев удаляемых постов, потом у
+		// самих удаляемых комментариев
+		query := `
+            WITH doomed AS (
+                SELECT c.id FROM Comments c
+                JOIN Posts p ON p.id = c.post_id
+                WHERE p.` + expired + `
+            ),
+            revisions AS (
+                DELETE FROM Revisions WHERE item_type = 'comment' AND item_id IN (SELECT id FROM doomed)
+            )
+            DELETE FROM Reports WHERE item_type = 'comment' AND item_id IN (SELECT id FROM doomed)`
+		if _, err := tx.Exec(ctx, query, seconds); err != nil {
+			return fmt.Errorf("ошибка при очистке комментариев удаленных постов: %w", err)
+		}
+
+		query = `
+            WITH purged AS (
+                DELETE FROM Posts WHERE ` + expired + ` RETURNING id
+            ),
+            revisions AS (
+                DELETE FROM Revisions WHERE item_type = 'post' AND item_id IN (SELECT id FROM purged)
+            ),
+            reports AS (
+                DELETE FROM Reports WHERE item_type = 'post' AND item_id IN (SELECT id FROM purged)
+            )
+            SELECT count(*) FROM purged`
+		if err := tx.QueryOne(ctx, &posts, query, seconds); err != nil {
+			return fmt.Errorf("ошибка при очистке постов: %w", err)
+		}
+
+		query = `
+            WITH purged AS (
+                DELETE FROM Comments c
+                WHERE c.` + expired + `
+                  AND NOT EXISTS (SELECT 1 FROM Comments r WHERE r.parent_id = c.id)
+                RETURNING c.id
+            ),
+            revisions AS (
+                DELETE FROM Revisions WHERE item_type = 'comment' AND item_id IN (SELECT id FROM purged)
+            ),
+            reports AS (
+                DELETE FROM Reports WHERE item_type = 'comment' AND item_id IN (SELECT id FROM purged)
+            ),
+            saved AS (
+                DELETE FROM Saved WHERE item_type = 'comment' AND item_id IN (SELECT id FROM purged)
+            )
+            SELECT count(*) FROM purged`
+		if err := tx.QueryOne(ctx, &comments, query, seconds); err != nil {
+			return fmt.Errorf("ошибка при очистке комментариев: %w", err)
+		}
+
+		return nil
+	})
+
+	return posts, comments, err
+}
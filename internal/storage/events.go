@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+	"strconv"
+	"time"
+)
+
+// EventsChannel — канал NOTIFY, которым экземпляры приложения будят друг друга
+// после публикации событий
+const EventsChannel = "reddit_events"
+
+// eventColumns — столбцы события. Позиция — ID транзакции, опубликовавшей событие.
+const eventColumns = `e.id, e.type, e.post_id, COALESCE(e.category, '') AS category, e.data, e.created,
+    e.tx::text::bigint AS position`
+
+// eventVisible отсекает события транзакций, старше которых еще могут
+// закоммититься другие: событие с меньшей позицией не должно появиться после
+// того, как клиент прочитал большую
+const eventVisible = `e.tx < pg_snapshot_xmin(pg_current_snapshot())`
+
+// PublishEvent сохраняет событие и будит все экземпляры приложения. Уведомление
+// уходит при коммите, поэтому получатели уже могут прочитать событие.
+//
+// Каждое событие публикуется в своей транзакции: позиция в потоке — ID
+// транзакции, и два события одной транзакции получили бы одну позицию.
+func (s *RedditDB) PublishEvent(event *models.Event) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		query := `
+            INSERT INTO Events (type, post_id, category, data)
+            VALUES ($1, $2, NULLIF($3, ''), $4)
+            RETURNING id, created, tx::text::bigint AS position`
+		err := tx.QueryOne(ctx, event, query, event.Type, event.PostID, event.Category, event.Data)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении события: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `SELECT pg_notify($1, '')`, EventsChannel)
+		if err != nil {
+			return fmt.Errorf("ошибка при отправке уведомления о событии: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetEventHorizon возвращает позицию, до которой включительно набор событий
+// уже не изменится
+func (s *RedditDB) GetEventHorizon() (int64, error) {
+	var horizon int64
+	query := `SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint - 1`
+	err := s.db.QueryOne(context.Background(), &horizon, query)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении горизонта событий: %w", err)
+	}
+	return horizon, nil
+}
+
+// GetNewEvents возвращает до limit видимых событий всех постов с позицией больше after по порядку
+func (s *RedditDB) GetNewEvents(after int64, limit int) ([]*models.Event, error) {
+	var events []*models.Event
+	query := `
+        SELECT ` + eventColumns + `
+        FROM Events e
+        WHERE e.tx > $1::text::xid8 AND ` + eventVisible + `
+        ORDER BY e.tx
+        LIMIT $2`
+	err := s.db.QueryMany(context.Background(), &events, query, strconv.FormatInt(after, 10), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении новых событий: %w", err)
+	}
+	return events, nil
+}
+
+// GetEventsSince возвращает до limit видимых событий поста или категории с
+// позицией больше after по порядку
+func (s *RedditDB) GetEventsSince(after int64, filter models.EventFilter, limit int) ([]*models.Event, error) {
+	var events []*models.Event
+
+	condition, value := "e.category = $2", any(filter.Category)
+	if filter.PostID != 0 {
+		condition, value = "e.post_id = $2", filter.PostID
+	}
+
+	query := `
+        SELECT ` + eventColumns + `
+        FROM Events e
+        WHERE e.tx > $1::text::xid8 AND ` + condition + ` AND ` + eventVisible + `
+        ORDER BY e.tx
+        LIMIT $3`
+	err := s.db.QueryMany(context.Background(), &events, query, strconv.FormatInt(after, 10), value, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении пропущенных событий: %w", err)
+	}
+	return events, nil
+}
+
+// PruneEvents удаляет события старше retention и возвращает их число
+func (s *RedditDB) PruneEvents(retention time.Duration) (int64, error) {
+	query := `DELETE FROM Events WHERE created < CURRENT_TIMESTAMP - make_interval(secs => $1)`
+	tag, err := s.db.Exec(context.Background(), query, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при очистке журнала событий: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListenEvents вызывает handle на каждое уведомление о новых событиях, пока не
+// отменен ctx или не оборвалось подключение
+func (s *RedditDB) ListenEvents(ctx context.Context, handle func()) error {
+	return s.db.Listen(ctx, EventsChannel, func(string) {
+		handle()
+	})
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"slices"
+	"strings"
+)
+
+// postColumns — столбцы поста и автора, которые отдаются во всех списках
+const postColumns = `
+            p.id, p.title, p.url, COALESCE(p.category, '') AS category, p.score, p.created, p.views, p.type, p.text, p.edited,
+            p.removed, p.approved, p.locked, p.pinned, p.deleted_at IS NOT NULL AS deleted,
+            u.id AS "author.id",
+            u.username AS "author.username"`
+
+// windowIntervals — длительность временных окон для top и controversial
+var windowIntervals = map[string]string{
+	models.WindowHour:  "1 hour",
+	models.WindowDay:   "1 day",
+	models.WindowWeek:  "7 days",
+	models.WindowMonth: "1 month",
+	models.WindowYear:  "1 year",
+}
+
+// sortKey возвращает SQL-выражение ключа сортировки для режима page.Sort и
+// условия, которые режим добавляет к выборке. Выражения hot, top и controversial
+// совпадают с индексами из миграций.
+func sortKey(page models.Page, args []any) (string, []string, []any) {
+	var conditions []string
+
+	// Момент отсчета передается как unix-время и приводится к timestamp в часовом
+	// поясе сессии, так же как CURRENT_TIMESTAMP при вставке поста. Параметр
+	// добавляется только если используется: Postgres не выводит тип неиспользуемых.
+	at := ""
+	reference := func() string {
+		if at == "" {
+			args = append(args, page.At.Unix())
+			at = fmt.Sprintf("to_timestamp($%d)::timestamp", len(args))
+		}
+		return at
+	}
+
+	if interval, ok := windowIntervals[page.Window]; ok && (page.Sort == models.SortTop || page.Sort == models.SortControversial) {
+		ref := reference()
+		args = append(args, interval)
+		conditions = append(conditions, fmt.Sprintf("p.created > %s - $%d::interval", ref, len(args)))
+	}
+
+	switch page.Sort {
+	case models.SortHot:
+		return "hot_rank(p.score, p.created)", conditions, args
+	case models.SortTop:
+		return "p.score::float8", conditions, args
+	case models.SortControversial:
+		return "controversy_rank(p.upvotes, p.downvotes)", conditions, args
+	case models.SortRising:
+		// Набирающие популярность: посты за последние сутки, score делится на возраст
+		ref := reference()
+		conditions = append(conditions, fmt.Sprintf("p.created > %s - interval '1 day'", ref))
+		key := fmt.Sprintf("p.score::float8 / power(greatest(extract(epoch FROM (%s - p.created))::float8, 0) / 3600 + 2, 1.5)", ref)
+		return key, conditions, args
+	default:
+		return "extract(epoch FROM p.created)::float8", conditions, args
+	}
+}
+
+// listPosts выбирает страницу постов, удовлетворяющих условию where, с keyset-пагинацией
+// по паре (ключ сортировки, id). Аргументы условия нумеруются с $1.
+func (s *RedditDB) listPosts(ctx context.Context, where string, args []any, page models.Page) ([]*models.Post, error) {
+	var posts []*models.Post
+
+	conditions := []string{"NOT p.removed", "p.deleted_at IS NULL"}
+	if where != "" {
+		conditions = append(conditions, where)
+	}
+
+	viewerConditions, args := viewerFilters(page.Viewer, "p.id", "p.author_id", args)
+	conditions = append(conditions, viewerConditions...)
+
+	key, sortConditions, args := sortKey(page, args)
+	conditions = append(conditions, sortConditions...)
+
+	cursorCondition, order, args := keyset(key, "p.id", page, args)
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT %s,
+            %s AS sort_key
+        FROM Posts p
+        JOIN Users u ON u.id = p.author_id
+        WHERE %s
+        ORDER BY sort_key %s, p.id %s
+        LIMIT $%d`, postColumns, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(ctx, &posts, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Before != nil {
+		slices.Reverse(posts)
+	}
+
+	return posts, nil
+}
+
+// viewerFilters возвращает условия, скрывающие от пользователя viewerID посты,
+// которые он скрыл, и записи заблокированных им авторов. postID и authorID —
+// столбцы поста и автора записи. Для анонимов условий нет.
+func viewerFilters(viewerID int, postID string, authorID string, args []any) ([]string, []any) {
+	if viewerID == 0 {
+		return nil, args
+	}
+
+	args = append(args, viewerID)
+	return []string{
+		fmt.Sprintf("NOT EXISTS (SELECT 1 FROM HiddenPosts h WHERE h.user_id = $%d AND h.post_id = %s)", len(args), postID),
+		fmt.Sprintf("NOT EXISTS (SELECT 1 FROM Blocks b WHERE b.user_id = $%d AND b.blocked_id = %s)", len(args), authorID),
+	}, args
+}
+
+// keyset возвращает условие курсора для пары (key, idColumn) и направление сортировки.
+// Предыдущая страница читается в обратном порядке, поэтому результат такого
+// запроса вызывающий должен развернуть.
+func keyset(key string, idColumn string, page models.Page, args []any) (string, string, []any) {
+	switch {
+	case page.Before != nil:
+		args = append(args, page.Before.Key, page.Before.ID)
+		return fmt.Sprintf("(%s, %s) > ($%d, $%d)", key, idColumn, len(args)-1, len(args)), "ASC", args
+	case page.After != nil:
+		args = append(args, page.After.Key, page.After.ID)
+		return fmt.Sprintf("(%s, %s) < ($%d, $%d)", key, idColumn, len(args)-1, len(args)), "DESC", args
+	default:
+		return "", "DESC", args
+	}
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrMessageNotFound    = errors.New("сообщение не найдено")
+	ErrMessageRateLimited = errors.New("слишком много сообщений, попробуйте позже")
+)
+
+// visibleMessages — условие сообщений, которые пользователь $1 отправил или
+// получил и не удалил у себя
+const visibleMessages = `((m.sender_id = $1 AND NOT m.sender_deleted) OR (m.recipient_id = $1 AND NOT m.recipient_deleted))`
+
+// messageColumns — столбцы сообщения, отправителя и получателя
+const messageColumns = `
+            m.id, m.body, m.created, m.read_at IS NOT NULL AS read,
+            s.id AS "sender.id",
+            s.username AS "sender.username",
+            r.id AS "recipient.id",
+            r.username AS "recipient.username"`
+
+// SendMessage сохраняет сообщение, если отправитель за последние window
+// отправил меньше limit сообщений; limit 0 снимает ограничение. Отправки одного
+// пользователя выстраиваются в очередь на блокировке, чтобы параллельные запросы
+// не превысили limit.
+func (s *RedditDB) SendMessage(message *models.Message, limit int, window time.Duration) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		if limit > 0 {
+			_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock('messages'::regclass::oid::int, $1)`, message.Sender.ID)
+			if err != nil {
+				return fmt.Errorf("ошибка при блокировке отправителя: %w", err)
+			}
+
+			var sent int
+			query := `
+                SELECT count(*) FROM Messages
+                WHERE sender_id = $1 AND created > CURRENT_TIMESTAMP - make_interval(secs => $2)`
+			if err := tx.QueryOne(ctx, &sent, query, message.Sender.ID, window.Seconds()); err != nil {
+				return fmt.Errorf("ошибка при подсчете отправленных сообщений: %w", err)
+			}
+			if sent >= limit {
+				return ErrMessageRateLimited
+			}
+		}
+
+		query := `
+            INSERT INTO Messages (sender_id, recipient_id, body)
+            VALUES ($1, $2, $3)
+            RETURNING id, created`
+		err := tx.QueryOne(ctx, message, query, message.Sender.ID, message.Recipient.ID, message.Body)
+		if err != nil {
+			return fmt.Errorf("ошибка при отправке сообщения: %w", err)
+		}
+		return nil
+	})
+}
+
+// IsBlockedEither сообщает, заблокировал ли кто-то из двух пользователей другого
+func (s *RedditDB) IsBlockedEither(userID int, otherID int) (bool, error) {
+	var blocked bool
+	query := `
+        SELECT EXISTS (
+            SELECT 1 FROM Blocks
+            WHERE (user_id = $1 AND blocked_id = $2) OR (user_id = $2 AND blocked_id = $1)
+        )`
+	err := s.db.QueryOne(context.Background(), &blocked, query, userID, otherID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке блокировки: %w", err)
+	}
+	return blocked, nil
+}
+
+// GetConversations возвращает страницу переписок пользователя с последним
+// видимым ему сообщением каждой, самые свежие первыми
+func (s *RedditDB) GetConversations(userID int, page models.Page) ([]*models.Conversation, error) {
+	var conversations []*models.Conversation
+
+	const key = "extract(epoch FROM l.created)::float8"
+	cursorCondition, order, args := keyset(key, "l.id", page, []any{userID})
+	if cursorCondition == "" {
+		cursorCondition = "TRUE"
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        WITH visible AS (
+            SELECT m.*, CASE WHEN m.sender_id = $1 THEN m.recipient_id ELSE m.sender_id END AS other_id
+            FROM Messages m
+            WHERE %s
+        ),
+        latest AS (
+            SELECT DISTINCT ON (other_id) *
+            FROM visible
+            ORDER BY other_id, created DESC, id DESC
+        )
+        SELECT
+            o.id AS "with.id",
+            o.username AS "with.username",
+            l.id AS "last_message.id",
+            l.body AS "last_message.body",
+            l.created AS "last_message.created",
+            l.read_at IS NOT NULL AS "last_message.read",
+            s.id AS "last_message.sender.id",
+            s.username AS "last_message.sender.username",
+            r.id AS "last_message.recipient.id",
+            r.username AS "last_message.recipient.username",
+            (SELECT count(*) FROM visible v
+             WHERE v.other_id = l.other_id AND v.recipient_id = $1 AND v.read_at IS NULL) AS unread,
+            %s AS sort_key
+        FROM latest l
+        JOIN Users o ON o.id = l.other_id
+        JOIN Users s ON s.id = l.sender_id
+        JOIN Users r ON r.id = l.recipient_id
+        WHERE %s
+        ORDER BY sort_key %s, l.id %s
+        LIMIT $%d`, visibleMessages, key, cursorCondition, order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &conversations, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении переписок: %w", err)
+	}
+
+	if page.Before != nil {
+		slices.Reverse(conversations)
+	}
+
+	return conversations, nil
+}
+
+// GetMessages возвращает страницу переписки пользователя с otherID, новые сообщения первыми
+func (s *RedditDB) GetMessages(userID int, otherID int, page models.Page) ([]*models.Message, error) {
+	var messages []*models.Message
+
+	const key = "extract(epoch FROM m.created)::float8"
+	conditions := []string{visibleMessages, "(m.sender_id = $2 OR m.recipient_id = $2)"}
+	cursorCondition, order, args := keyset(key, "m.id", page, []any{userID, otherID})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT %s,
+            %s AS sort_key
+        FROM Messages m
+        JOIN Users s ON s.id = m.sender_id
+        JOIN Users r ON r.id = m.recipient_id
+        WHERE %s
+        ORDER BY sort_key %s, m.id %s
+        LIMIT $%d`, messageColumns, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &messages, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сообщений: %w", err)
+	}
+
+	if page.Before != nil {
+		slices.Reverse(messages)
+	}
+
+	return messages, nil
+}
+
+// MarkMessagesRead отмечает прочитанными все сообщения, которые пользователь получил от otherID
+func (s *RedditDB) MarkMessagesRead(userID int, otherID int) error {
+	query := `
+        UPDATE Messages SET read_at = CURRENT_TIMESTAMP
+        WHERE recipient_id = $1 AND sender_id = $2 AND read_at IS NULL`
+	_, err := s.db.Exec(context.Background(), query, userID, otherID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отметке сообщений: %w", err)
+	}
+	return nil
+}
+
+// DeleteMessage скрывает сообщение у пользователя. Сообщение, удаленное обоими
+// участниками, стирается.
+func (s *RedditDB) DeleteMessage(userID int, messageID int) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var id int
+		query := `
+            UPDATE Messages m
+            SET sender_deleted = sender_deleted OR sender_id = $1,
+                recipient_deleted = recipient_deleted OR recipient_id = $1
+            WHERE m.id = $2 AND ` + visibleMessages + `
+            RETURNING m.id`
+		err := tx.QueryOne(ctx, &id, query, userID, messageID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrMessageNotFound
+			}
+			return fmt.Errorf("ошибка при удалении сообщения: %w", err)
+		}
+
+		query = `DELETE FROM Messages WHERE id = $1 AND sender_deleted AND recipient_deleted`
+		if _, err := tx.Exec(ctx, query, messageID); err != nil {
+			return fmt.Errorf("ошибка при удалении сообщения: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *RedditDB) CountUnreadMessages(userID int) (int, error) {
+	var count int
+	query := `
+        SELECT count(*) FROM Messages
+        WHERE recipient_id = $1 AND read_at IS NULL AND NOT recipient_deleted`
+	err := s.db.QueryOne(context.Background(), &count, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете сообщений: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxPinnedPosts — сколько постов можно закрепить в одной категории
+const MaxPinnedPosts = 2
+
+var (
+	ErrUnknownModAction = errors.New("неизвестное действие модератора")
+	ErrTooManyPinned    = fmt.Errorf("в категории нельзя закрепить больше %d постов", MaxPinnedPosts)
+	ErrUserNotFound     = errors.New("пользователь не найден")
+)
+
+// postModeration и commentModeration — изменения, которые вносит каждое действие модератора
+var (
+	postModeration = map[string]string{
+		models.ModRemove:  "removed = TRUE, approved = FALSE, pinned = FALSE",
+		models.ModApprove: "removed = FALSE, approved = TRUE",
+		models.ModLock:    "locked = TRUE",
+		models.ModUnlock:  "locked = FALSE",
+		models.ModPin:     "pinned = TRUE",
+		models.ModUnpin:   "pinned = FALSE",
+	}
+	commentModeration = map[string]string{
+		models.ModRemove:  "removed = TRUE, approved = FALSE",
+		models.ModApprove: "removed = FALSE, approved = TRUE",
+	}
+)
+
+// ModeratePost применяет к посту действие entry.Action и записывает его в журнал
+func (s *RedditDB) ModeratePost(idPost int, entry *models.ModAction) error {
+	if _, ok := postModeration[entry.Action]; !ok {
+		return ErrUnknownModAction
+	}
+
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var category string
+		err := tx.QueryOne(ctx, &category, `SELECT COALESCE(category, '') FROM Posts WHERE id = $1 FOR UPDATE`, idPost)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPostNotFound
+			}
+			return fmt.Errorf("ошибка при поиске поста: %w", err)
+		}
+
+		if entry.Action == models.ModPin {
+			// Блокируем строку сообщества, чтобы параллельные закрепления не превысили лимит
+			_, err = tx.Exec(ctx, `SELECT 1 FROM Communities WHERE name = $1 FOR UPDATE`, category)
+			if err != nil {
+				return fmt.Errorf("ошибка при поиске сообщества: %w", err)
+			}
+
+			var pinned int
+			query := `SELECT count(*) FROM Posts WHERE category = $1 AND pinned AND id <> $2`
+			if err := tx.QueryOne(ctx, &pinned, query, category, idPost); err != nil {
+				return fmt.Errorf("ошибка при подсчете закрепленных постов: %w", err)
+			}
+			if pinned >= MaxPinnedPosts {
+				return ErrTooManyPinned
+			}
+		}
+
+		if err := setModeration(ctx, tx, models.ItemPost, idPost, entry.Action); err != nil {
+			return err
+		}
+
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemPost, idPost
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// ModerateComment применяет к комментарию действие entry.Action и записывает его в журнал
+func (s *RedditDB) ModerateComment(idPost int, commentID int, entry *models.ModAction) error {
+	set, ok := commentModeration[entry.Action]
+	if !ok {
+		return ErrUnknownModAction
+	}
+
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var category string
+		query := fmt.Sprintf(`
+            UPDATE Comments c SET %s
+            FROM Posts p
+            WHERE c.id = $1 AND c.post_id = $2 AND p.id = c.post_id
+            RETURNING COALESCE(p.category, '')`, set)
+		err := tx.QueryOne(ctx, &category, query, commentID, idPost)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCommentNotFound
+			}
+			return fmt.Errorf("ошибка при модерации комментария: %w", err)
+		}
+
+		entry.Category = category
+		entry.ItemType, entry.ItemID = models.ItemComment, commentID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// GetPinnedPosts возвращает закрепленные посты категории, новые первыми.
+// Посты, скрытые пользователем viewerID, и посты заблокированных им авторов пропускаются.
+func (s *RedditDB) GetPinnedPosts(category string, viewerID int) ([]*models.Post, error) {
+	var posts []*models.Post
+
+	conditions := []string{"p.category = $1", "p.pinned", "NOT p.removed"}
+	viewerConditions, args := viewerFilters(viewerID, "p.id", "p.author_id", []any{category})
+	conditions = append(conditions, viewerConditions...)
+
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM Posts p
+        JOIN Users u ON u.id = p.author_id
+        WHERE %s
+        ORDER BY p.created DESC, p.id DESC`, postColumns, strings.Join(conditions, " AND "))
+	err := s.db.QueryMany(context.Background(), &posts, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении закрепленных постов: %w", err)
+	}
+	return posts, nil
+}
+
+func (s *RedditDB) AddModerator(username string, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var userID int
+		query := `
+            INSERT INTO Moderators (user_id, category)
+            SELECT id, $2 FROM Users WHERE username = $1
+            ON CONFLICT (user_id, category) DO UPDATE SET category = EXCLUDED.category
+            RETURNING user_id`
+		err := tx.QueryOne(ctx, &userID, query, username, entry.Category)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("ошибка при назначении модератора: %w", err)
+		}
+
+		entry.ItemType, entry.ItemID = models.ItemUser, userID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+func (s *RedditDB) RemoveModerator(username string, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var userID int
+		query := `
+            DELETE FROM Moderators m
+            USING Users u
+            WHERE u.username = $1 AND m.user_id = u.id AND m.category = $2
+            RETURNING m.user_id`
+		err := tx.QueryOne(ctx, &userID, query, username, entry.Category)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("ошибка при снятии модератора: %w", err)
+		}
+
+		entry.ItemType, entry.ItemID = models.ItemUser, userID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+func (s *RedditDB) GetModerators(category string) ([]models.User, error) {
+	var moderators []models.User
+	query := `
+        SELECT u.id, u.username
+        FROM Moderators m
+        JOIN Users u ON u.id = m.user_id
+        WHERE m.category = $1
+        ORDER BY u.username`
+	err := s.db.QueryMany(context.Background(), &moderators, query, category)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении модераторов: %w", err)
+	}
+	return moderators, nil
+}
+
+// GetModLog возвращает журнал модерации категории, новые записи первыми
+func (s *RedditDB) GetModLog(category string, page models.Page) ([]*models.ModAction, error) {
+	var actions []*models.ModAction
+
+	key := "extract(epoch FROM l.created)::float8"
+	conditions := []string{"l.category = $1"}
+	cursorCondition, order, args := keyset(key, "l.id", page, []any{category})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT l.id, COALESCE(l.category, '') AS category, l.action, l.item_type, l.item_id, l.reason, l.created,
+            COALESCE(u.id, 0) AS "actor.id",
+            COALESCE(u.username, '') AS "actor.username",
+            %s AS sort_key
+        FROM ModLog l
+        LEFT JOIN Users u ON u.id = l.actor_id
+        WHERE %s
+        ORDER BY sort_key %s, l.id %s
+        LIMIT $%d`, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &actions, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении журнала модерации: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(actions)
+	}
+	return actions, nil
+}
+
+// GetModQueuePosts возвращает посты категории, которые модераторы еще не одобрили и не удалили
+func (s *RedditDB) GetModQueuePosts(category string, page models.Page) ([]*models.ModQueueItem, error) {
+	var posts []*models.Post
+
+	key := "extract(epoch FROM p.created)::float8"
+	conditions := []string{"p.category = $1", "NOT p.removed", "NOT p.approved", "p.deleted_at IS NULL"}
+	cursorCondition, order, args := keyset(key, "p.id", page, []any{category})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT %s,
+            %s AS sort_key
+        FROM Posts p
+        JOIN Users u ON u.id = p.author_id
+        WHERE %s
+        ORDER BY sort_key %s, p.id %s
+        LIMIT $%d`, postColumns, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &posts, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении очереди модерации: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(posts)
+	}
+
+	items := make([]*models.ModQueueItem, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, &models.ModQueueItem{
+			Type:      models.ItemPost,
+			Post:      post,
+			PostID:    post.ID,
+			PostTitle: post.Title,
+			SortKey:   post.SortKey,
+		})
+	}
+	return items, nil
+}
+
+// GetModQueueComments возвращает комментарии категории, которые модераторы еще не одобрили и не удалили
+func (s *RedditDB) GetModQueueComments(category string, page models.Page) ([]*models.ModQueueItem, error) {
+	var rows []*struct {
+		models.Comment
+		PostID    int     `db:"post_id"`
+		PostTitle string  `db:"post_title"`
+		SortKey   float64 `db:"sort_key"`
+	}
+
+	key := "extract(epoch FROM c.created)::float8"
+	conditions := []string{"p.category = $1", "NOT c.removed", "NOT c.approved", "c.deleted_at IS NULL"}
+	cursorCondition, order, args := keyset(key, "c.id", page, []any{category})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT
+            c.id, c.parent_id, c.depth, c.body, c.created, c.edited, c.score, c.removed, c.approved,
+            u.id AS "author.id",
+            u.username AS "author.username",
+            p.id AS post_id,
+            p.title AS post_title,
+            %s AS sort_key
+        FROM Comments c
+        JOIN Posts p ON p.id = c.post_id
+        JOIN Users u ON u.id = c.author_id
+        WHERE %s
+        ORDER BY sort_key %s, c.id %s
+        LIMIT $%d`, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении очереди модерации: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	items := make([]*models.ModQueueItem, 0, len(rows))
+	for _, row := range rows {
+		comment := row.Comment
+		items = append(items, &models.ModQueueItem{
+			Type:      models.ItemComment,
+			Comment:   &comment,
+			PostID:    row.PostID,
+			PostTitle: row.PostTitle,
+			SortKey:   row.SortKey,
+		})
+	}
+	return items, nil
+}
+
+// logModAction записывает действие модератора в журнал в рамках транзакции действия.
+// Actor.ID 0 означает автоматическое действие.
+func logModAction(ctx context.Context, tx pg.Tx, entry *models.ModAction) error {
+	query := `
+        INSERT INTO ModLog (actor_id, category, action, item_type, item_id, reason)
+        VALUES (NULLIF($1, 0), NULLIF($2, ''), $3, $4, $5, $6)
+        RETURNING id, created`
+	err := tx.QueryOne(ctx, entry, query, entry.Actor.ID, entry.Category, entry.Action, entry.ItemType, entry.ItemID, entry.Reason)
+	if err != nil {
+		return fmt.Errorf("ошибка при записи в журнал модерации: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+	"slices"
+	"strings"
+)
+
+// GetUserIDs возвращает ID существующих пользователей из usernames: имя → ID
+func (s *RedditDB) GetUserIDs(usernames []string) (map[string]int, error) {
+	var users []models.User
+	query := `SELECT id, username FROM Users WHERE username = ANY($1)`
+	err := s.db.QueryMany(context.Background(), &users, query, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске пользователей: %w", err)
+	}
+
+	result := make(map[string]int, len(users))
+	for _, user := range users {
+		result[user.Username] = user.ID
+	}
+	return result, nil
+}
+
+// CreateNotifications сохраняет уведомления. Пользователь не получает
+// уведомлений о действиях заблокированных им авторов, а повторные
+// уведомления об одной и той же отметке оценки пропускаются.
+func (s *RedditDB) CreateNotifications(notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		query := `
+            INSERT INTO Notifications (user_id, type, actor_id, post_id, comment_id, milestone)
+            SELECT $1, $2, NULLIF($3, 0), $4, $5, NULLIF($6, 0)
+            WHERE NOT EXISTS (SELECT 1 FROM Blocks b WHERE b.user_id = $1 AND b.blocked_id = $3)
+            ON CONFLICT DO NOTHING`
+		for _, n := range notifications {
+			_, err := tx.Exec(ctx, query, n.UserID, n.Type, n.Actor.ID, n.PostID, n.CommentID, n.Milestone)
+			if err != nil {
+				return fmt.Errorf("ошибка при создании уведомления: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetNotifications возвращает страницу уведомлений пользователя, новые первыми.
+// Текст ответов и упоминаний берется из текущей версии комментария и пуст,
+// если комментарий удален или снят модератором.
+func (s *RedditDB) GetNotifications(userID int, unreadOnly bool, page models.Page) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+
+	const key = "extract(epoch FROM n.created)::float8"
+	conditions := []string{"n.user_id = $1"}
+	if unreadOnly {
+		conditions = append(conditions, "NOT n.read")
+	}
+
+	args := []any{userID, models.NotifyPostReply, models.NotifyCommentReply, models.NotifyMention}
+	cursorCondition, order, args := keyset(key, "n.id", page, args)
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT n.id, n.user_id, n.type, n.post_id, n.comment_id,
+            CASE WHEN n.type IN ($2, $3, $4) AND NOT c.removed AND c.deleted_at IS NULL
+                THEN c.body ELSE '' END AS body,
+            COALESCE(n.milestone, 0) AS milestone, n.read, n.created,
+            p.title AS post_title,
+            COALESCE(a.id, 0) AS "actor.id",
+            COALESCE(a.username, '') AS "actor.username",
+            %s AS sort_key
+        FROM Notifications n
+        JOIN Posts p ON p.id = n.post_id
+        LEFT JOIN Comments c ON c.id = n.comment_id
+        LEFT JOIN Users a ON a.id = n.actor_id
+        WHERE %s
+        ORDER BY sort_key %s, n.id %s
+        LIMIT $%d`, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &notifications, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении уведомлений: %w", err)
+	}
+
+	if page.Before != nil {
+		slices.Reverse(notifications)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationsRead отмечает прочитанными уведомления ids или, если ids пуст, все уведомления пользователя
+func (s *RedditDB) MarkNotificationsRead(userID int, ids []int) error {
+	query := `UPDATE Notifications SET read = TRUE WHERE user_id = $1 AND NOT read`
+	args := []any{userID}
+	if len(ids) > 0 {
+		query += ` AND id = ANY($2)`
+		args = append(args, ids)
+	}
+
+	_, err := s.db.Exec(context.Background(), query, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка при отметке уведомлений: %w", err)
+	}
+	return nil
+}
+
+func (s *RedditDB) CountUnreadNotifications(userID int) (int, error) {
+	var count int
+	query := `SELECT count(*) FROM Notifications WHERE user_id = $1 AND NOT read`
+	err := s.db.QueryOne(context.Background(), &count, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете уведомлений: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrPostNotFound    = errors.New("пост не найден")
+	ErrCommentNotFound = errors.New("комментарий не найден")
+)
+
+// Owner — автор и категория поста или комментария, нужные для проверки прав
+type Owner struct {
+	AuthorID int    `db:"author_id"`
+	Category string `db:"category"`
+	Locked   bool   `db:"locked"`  // пост закрыт для новых комментариев
+	Removed  bool   `db:"removed"` // пост удален модератором
+	Deleted  bool   `db:"deleted"` // запись или ее пост мягко удалены
+}
+
+func (s *RedditDB) GetPostOwner(idPost int) (Owner, error) {
+	var owner Owner
+	query := `
+        SELECT author_id, COALESCE(category, '') AS category, locked, removed,
+            deleted_at IS NOT NULL AS deleted
+        FROM Posts WHERE id = $1`
+	err := s.db.QueryOne(context.Background(), &owner, query, idPost)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return owner, ErrPostNotFound
+		}
+		return owner, fmt.Errorf("ошибка при поиске поста: %w", err)
+	}
+	return owner, nil
+}
+
+func (s *RedditDB) GetCommentOwner(idPost int, commentID int) (Owner, error) {
+	var owner Owner
+	query := `
+        SELECT c.author_id, COALESCE(p.category, '') AS category, p.locked, p.removed,
+            c.deleted_at IS NOT NULL OR p.deleted_at IS NOT NULL AS deleted
+        FROM Comments c
+        JOIN Posts p ON p.id = c.post_id
+        WHERE c.id = $1 AND c.post_id = $2`
+	err := s.db.QueryOne(context.Background(), &owner, query, commentID, idPost)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return owner, ErrCommentNotFound
+		}
+		return owner, fmt.Errorf("ошибка при поиске комментария: %w", err)
+	}
+	return owner, nil
+}
+
+func (s *RedditDB) GetUserRole(userID int) (string, error) {
+	var role string
+	err := s.db.QueryOne(context.Background(), &role, `SELECT role FROM Users WHERE id = $1`, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("пользователь с ID %d не найден", userID)
+		}
+		return "", fmt.Errorf("ошибка при получении роли пользователя: %w", err)
+	}
+	return role, nil
+}
+
+func (s *RedditDB) IsModerator(userID int, category string) (bool, error) {
+	var isModerator bool
+	query := `SELECT EXISTS(SELECT 1 FROM Moderators WHERE user_id = $1 AND category = $2)`
+	err := s.db.QueryOne(context.Background(), &isModerator, query, userID, category)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке модератора: %w", err)
+	}
+	return isModerator, nil
+}
+
+// SetUserRole меняет роль пользователя. Если роль изменилась, все его сессии
+// отзываются: роль записана в токены, и старые токены не должны ее сохранять.
+func (s *RedditDB) SetUserRole(username string, role string) (models.User, error) {
+	ctx := context.Background()
+	var user models.User
+	err := s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var previous string
+		err := tx.QueryOne(ctx, &previous, `SELECT role FROM Users WHERE username = $1 FOR UPDATE`, username)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("пользователь %s не найден", username)
+			}
+			return fmt.Errorf("ошибка при изменении роли пользователя: %w", err)
+		}
+
+		query := `UPDATE Users SET role = $2 WHERE username = $1 RETURNING id, username, role`
+		if err := tx.QueryOne(ctx, &user, query, username, role); err != nil {
+			return fmt.Errorf("ошибка при изменении роли пользователя: %w", err)
+		}
+		if previous == role {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE Sessions SET revoked = TRUE WHERE user_id = $1 AND NOT revoked`, user.ID)
+		if err != nil {
+			return fmt.Errorf("ошибка при отзыве сессий пользователя: %w", err)
+		}
+		return nil
+	})
+	return user, err
+}
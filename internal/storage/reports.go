@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAlreadyReported = errors.New("вы уже пожаловались на эту запись")
+	ErrReportNotFound  = errors.New("жалоба не найдена")
+	ErrReportClosed    = errors.New("жалоба уже рассмотрена")
+)
+
+// reportedItem — состояние записи, на которую поступила жалоба
+type reportedItem struct {
+	PostID   int    `db:"post_id"`
+	Category string `db:"category"`
+	Approved bool   `db:"approved"`
+	Removed  bool   `db:"removed"`
+}
+
+// CreateReport сохраняет жалобу. Если открытых жалоб на запись стало не меньше
+// hideThreshold и модератор ее еще не одобрял, запись скрывается.
+// hideThreshold 0 отключает скрытие.
+func (s *RedditDB) CreateReport(report *models.Report, hideThreshold int) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var item reportedItem
+		var err error
+		switch report.ItemType {
+		case models.ItemPost:
+			query := `
+                SELECT id AS post_id, COALESCE(category, '') AS category, approved, removed
+                FROM Posts WHERE id = $1 FOR UPDATE`
+			err = tx.QueryOne(ctx, &item, query, report.ItemID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPostNotFound
+			}
+		case models.ItemComment:
+			query := `
+                SELECT c.post_id, COALESCE(p.category, '') AS category, c.approved, c.removed
+                FROM Comments c
+                JOIN Posts p ON p.id = c.post_id
+                WHERE c.id = $1 AND c.post_id = $2
+                FOR UPDATE OF c`
+			err = tx.QueryOne(ctx, &item, query, report.ItemID, report.PostID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCommentNotFound
+			}
+		default:
+			return fmt.Errorf("неизвестный тип записи %q", report.ItemType)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при поиске записи: %w", err)
+		}
+
+		report.PostID, report.Category = item.PostID, item.Category
+		query := `
+            INSERT INTO Reports (reporter_id, item_type, item_id, post_id, category, reason, details)
+            VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+            ON CONFLICT (reporter_id, item_type, item_id) DO NOTHING
+            RETURNING id, status, created`
+		err = tx.QueryOne(ctx, report, query,
+			report.ReporterID,
+			report.ItemType,
+			report.ItemID,
+			report.PostID,
+			report.Category,
+			report.Reason,
+			report.Details,
+		)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAlreadyReported
+			}
+			return fmt.Errorf("ошибка при сохранении жалобы: %w", err)
+		}
+
+		if hideThreshold <= 0 || item.Approved || item.Removed {
+			return nil
+		}
+
+		var open int
+		query = `SELECT count(*) FROM Reports WHERE item_type = $1 AND item_id = $2 AND status = 'open'`
+		if err := tx.QueryOne(ctx, &open, query, report.ItemType, report.ItemID); err != nil {
+			return fmt.Errorf("ошибка при подсчете жалоб: %w", err)
+		}
+		if open < hideThreshold {
+			return nil
+		}
+
+		if err := setModeration(ctx, tx, report.ItemType, report.ItemID, models.ModRemove); err != nil {
+			return err
+		}
+
+		return logModAction(ctx, tx, &models.ModAction{
+			Category: report.Category,
+			Action:   models.ModRemove,
+			ItemType: report.ItemType,
+			ItemID:   report.ItemID,
+			Reason:   fmt.Sprintf("скрыто автоматически: %d жалоб", open),
+		})
+	})
+}
+
+func (s *RedditDB) GetReport(reportID int) (*models.Report, error) {
+	var report models.Report
+	query := `
+        SELECT id, reporter_id, item_type, item_id, post_id, COALESCE(category, '') AS category,
+            reason, details, status, created
+        FROM Reports WHERE id = $1`
+	err := s.db.QueryOne(context.Background(), &report, query, reportID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReportNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении жалобы: %w", err)
+	}
+	return &report, nil
+}
+
+// GetOpenReports возвращает открытые жалобы категории, новые первыми
+func (s *RedditDB) GetOpenReports(category string, page models.Page) ([]*models.Report, error) {
+	var reports []*models.Report
+
+	key := "extract(epoch FROM r.created)::float8"
+	conditions := []string{"r.category = $1", "r.status = 'open'"}
+	cursorCondition, order, args := keyset(key, "r.id", page, []any{category})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT r.id, r.reporter_id, r.item_type, r.item_id, r.post_id, COALESCE(r.category, '') AS category,
+            r.reason, r.details, r.status, r.created,
+            %s AS sort_key
+        FROM Reports r
+        WHERE %s
+        ORDER BY sort_key %s, r.id %s
+        LIMIT $%d`, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &reports, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении жалоб: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(reports)
+	}
+	return reports, nil
+}
+
+// CloseReports закрывает все открытые жалобы на запись, к которой относится
+// жалоба reportID, со статусом status, применяет к записи entry.Action
+// и записывает действие в журнал модерации
+func (s *RedditDB) CloseReports(reportID int, status string, entry *models.ModAction) error {
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var report models.Report
+		query := `
+            SELECT id, item_type, item_id, COALESCE(category, '') AS category, status
+            FROM Reports WHERE id = $1 FOR UPDATE`
+		err := tx.QueryOne(ctx, &report, query, reportID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrReportNotFound
+			}
+			return fmt.Errorf("ошибка при получении жалобы: %w", err)
+		}
+		if report.Status != models.ReportOpen {
+			return ErrReportClosed
+		}
+
+		query = `
+            UPDATE Reports SET status = $3, resolved_by = $4, resolved_at = CURRENT_TIMESTAMP
+            WHERE item_type = $1 AND item_id = $2 AND status = 'open'`
+		_, err = tx.Exec(ctx, query, report.ItemType, report.ItemID, status, entry.Actor.ID)
+		if err != nil {
+			return fmt.Errorf("ошибка при закрытии жалоб: %w", err)
+		}
+
+		if err := setModeration(ctx, tx, report.ItemType, report.ItemID, entry.Action); err != nil {
+			return err
+		}
+
+		entry.Category = report.Category
+		entry.ItemType, entry.ItemID = report.ItemType, report.ItemID
+		return logModAction(ctx, tx, entry)
+	})
+}
+
+// setModeration применяет действие модератора к посту или комментарию без проверок
+func setModeration(ctx context.Context, tx pg.Tx, itemType string, itemID int, action string) error {
+	table, actions := "Posts", postModeration
+	if itemType == models.ItemComment {
+		table, actions = "Comments", commentModeration
+	}
+
+	set, ok := actions[action]
+	if !ok {
+		return ErrUnknownModAction
+	}
+
+	_, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET %s WHERE id = $1`, table, set), itemID)
+	if err != nil {
+		return fmt.Errorf("ошибка при модерации записи: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+)
+
+// UpdatePost сохраняет текущую версию поста в Revisions и применяет правку
+func (s *RedditDB) UpdatePost(idPost int, editorID int, edit *models.PostEdit) (*models.Post, error) {
+	ctx := context.Background()
+
+	err := s.db.WithTx(ctx, func(tx pg.Tx) error {
+		saveRevision := `
+            INSERT INTO Revisions (item_type, item_id, editor_id, title, url, body)
+            SELECT $1, id, $3, title, url, COALESCE(text, '')
+            FROM Posts
+            WHERE id = $2 AND deleted_at IS NULL`
+		tag, err := tx.Exec(ctx, saveRevision, models.ItemPost, idPost, editorID)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении версии поста: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrPostNotFound
+		}
+
+		updatePost := `
+            UPDATE Posts
+            SET title = COALESCE($2, title),
+                url = COALESCE($3, url),
+                text = COALESCE($4, text),
+                edited = CURRENT_TIMESTAMP
+            WHERE id = $1`
+		_, err = tx.Exec(ctx, updatePost, idPost, edit.Title, edit.URL, edit.Text)
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении поста: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPost(idPost)
+}
+
+// UpdateComment сохраняет текущую версию комментария в Revisions и заменяет текст
+func (s *RedditDB) UpdateComment(idPost int, commentID int, editorID int, body string) (*models.Post, error) {
+	ctx := context.Background()
+
+	err := s.db.WithTx(ctx, func(tx pg.Tx) error {
+		saveRevision := `
+            INSERT INTO Revisions (item_type, item_id, editor_id, body)
+            SELECT $1, id, $4, body
+            FROM Comments
+            WHERE id = $2 AND post_id = $3 AND deleted_at IS NULL`
+		tag, err := tx.Exec(ctx, saveRevision, models.ItemComment, commentID, idPost, editorID)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении версии комментария: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrCommentNotFound
+		}
+
+		updateComment := `UPDATE Comments SET body = $2, edited = CURRENT_TIMESTAMP WHERE id = $1`
+		_, err = tx.Exec(ctx, updateComment, commentID, body)
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении комментария: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPost(idPost)
+}
+
+// GetRevisions возвращает предыдущие версии объекта от старых к новым
+func (s *RedditDB) GetRevisions(itemType string, itemID int) ([]*models.Revision, error) {
+	var revisions []*models.Revision
+	query := `
+        SELECT
+            r.id, r.item_type, r.item_id, r.title, r.url, r.body, r.created,
+            COALESCE(u.id, 0) AS "editor.id",
+            COALESCE(u.username, '') AS "editor.username"
+        FROM Revisions r
+        LEFT JOIN Users u ON u.id = r.editor_id
+        WHERE r.item_type = $1 AND r.item_id = $2
+        ORDER BY r.created, r.id`
+	err := s.db.QueryMany(context.Background(), &revisions, query, itemType, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории правок: %w", err)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SaveItem сохраняет пост или комментарий в закладки пользователя.
+// postID — пост записи или пост комментария. Повторное сохранение ничего не меняет.
+func (s *RedditDB) SaveItem(userID int, itemType string, itemID int, postID int) error {
+	query := `
+        INSERT INTO Saved (user_id, item_type, item_id, post_id)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id, item_type, item_id) DO NOTHING`
+	_, err := s.db.Exec(context.Background(), query, userID, itemType, itemID, postID)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении: %w", err)
+	}
+	return nil
+}
+
+// UnsaveItem убирает запись из закладок пользователя
+func (s *RedditDB) UnsaveItem(userID int, itemType string, itemID int) error {
+	query := `DELETE FROM Saved WHERE user_id = $1 AND item_type = $2 AND item_id = $3`
+	_, err := s.db.Exec(context.Background(), query, userID, itemType, itemID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении из сохраненного: %w", err)
+	}
+	return nil
+}
+
+// GetSavedPosts возвращает, какие из постов postIDs пользователь сохранил
+func (s *RedditDB) GetSavedPosts(userID int, postIDs []int) (map[int]bool, error) {
+	var ids []int
+	query := `SELECT item_id FROM Saved WHERE user_id = $1 AND item_type = 'post' AND item_id = ANY($2)`
+	err := s.db.QueryMany(context.Background(), &ids, query, userID, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сохраненных постов: %w", err)
+	}
+
+	result := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetSavedComments возвращает ID сохраненных пользователем комментариев к посту
+func (s *RedditDB) GetSavedComments(postID int, userID int) (map[int]bool, error) {
+	var ids []int
+	query := `SELECT item_id FROM Saved WHERE post_id = $1 AND user_id = $2 AND item_type = 'comment'`
+	err := s.db.QueryMany(context.Background(), &ids, query, postID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сохраненных комментариев: %w", err)
+	}
+
+	result := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetSaved возвращает страницу сохраненного пользователем, последние сохранения
+// первыми. Удаленные записи и записи приватных сообществ пропускаются.
+func (s *RedditDB) GetSaved(userID int, filter models.SavedFilter, page models.Page) ([]*models.SavedItem, error) {
+	ctx := context.Background()
+
+	var rows []*struct {
+		ID       int       `db:"id"`
+		ItemType string    `db:"item_type"`
+		ItemID   int       `db:"item_id"`
+		Created  time.Time `db:"created"`
+		SortKey  float64   `db:"sort_key"`
+	}
+
+	const key = "extract(epoch FROM s.created)::float8"
+	args := []any{userID}
+	conditions := []string{
+		"s.user_id = $1",
+		"NOT p.removed", "p.deleted_at IS NULL",
+		"(c.id IS NULL OR (NOT c.removed AND c.deleted_at IS NULL))",
+		notPrivateCommunity,
+	}
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		conditions = append(conditions, fmt.Sprintf("p.category = $%d", len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("s.item_type = $%d", len(args)))
+	}
+
+	cursorCondition, order, args := keyset(key, "s.id", page, args)
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT s.id, s.item_type, s.item_id, s.created, %s AS sort_key
+        FROM Saved s
+        JOIN Posts p ON p.id = s.post_id
+        LEFT JOIN Comments c ON s.item_type = 'comment' AND c.id = s.item_id
+        WHERE %s
+        ORDER BY sort_key %s, s.id %s
+        LIMIT $%d`, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(ctx, &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сохраненного: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	var postIDs, commentIDs []int
+	for _, row := range rows {
+		if row.ItemType == models.ItemComment {
+			commentIDs = append(commentIDs, row.ItemID)
+		} else {
+			postIDs = append(postIDs, row.ItemID)
+		}
+	}
+
+	var posts []*models.Post
+	if len(postIDs) > 0 {
+		query := fmt.Sprintf(`
+            SELECT %s
+            FROM Posts p
+            JOIN Users u ON u.id = p.author_id
+            WHERE p.id = ANY($1)`, postColumns)
+		if err := s.db.QueryMany(ctx, &posts, query, postIDs); err != nil {
+			return nil, fmt.Errorf("ошибка при получении сохраненных постов: %w", err)
+		}
+	}
+
+	var comments []*models.UserComment
+	if len(commentIDs) > 0 {
+		query := fmt.Sprintf(`
+            SELECT %s
+            FROM Comments c
+            JOIN Users u ON u.id = c.author_id
+            JOIN Posts p ON p.id = c.post_id
+            WHERE c.id = ANY($1)`, userCommentColumns)
+		if err := s.db.QueryMany(ctx, &comments, query, commentIDs); err != nil {
+			return nil, fmt.Errorf("ошибка при получении сохраненных комментариев: %w", err)
+		}
+	}
+
+	postsByID := make(map[int]*models.Post, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+	commentsByID := make(map[int]*models.UserComment, len(comments))
+	for _, comment := range comments {
+		commentsByID[comment.ID] = comment
+	}
+
+	// Запись, удаленную между запросами, все равно нужно вернуть: по ней
+	// строится курсор. Без поста или комментария она просто пустая.
+	items := make([]*models.SavedItem, 0, len(rows))
+	for _, row := range rows {
+		item := &models.SavedItem{ID: row.ID, Type: row.ItemType, Saved: row.Created, SortKey: row.SortKey}
+		if row.ItemType == models.ItemComment {
+			item.Comment = commentsByID[row.ItemID]
+		} else {
+			item.Post = postsByID[row.ItemID]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
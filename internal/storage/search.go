@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reddit_v2/internal/models"
+	"slices"
+	"strings"
+)
+
+// headlineOptions — настройки ts_headline для фрагментов в результатах поиска
+const headlineOptions = `'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10'`
+
+// escapeHTML экранирует текст записи в SQL до подсветки, чтобы во фрагменте
+// разметкой были только теги <mark>
+func escapeHTML(expr string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`, expr)
+}
+
+// searchConditions добавляет фильтры поиска. author и created — столбцы автора
+// и даты создания найденной записи.
+func searchConditions(q models.SearchQuery, author string, created string, args []any) ([]string, []any) {
+	var conditions []string
+	if q.Category != "" {
+		args = append(args, q.Category)
+		conditions = append(conditions, fmt.Sprintf("p.category = $%d", len(args)))
+	}
+	if q.Author != "" {
+		args = append(args, q.Author)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", author, len(args)))
+	}
+	if q.From != nil {
+		args = append(args, *q.From)
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", created, len(args)))
+	}
+	if q.To != nil {
+		args = append(args, *q.To)
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", created, len(args)))
+	}
+	return conditions, args
+}
+
+func (s *RedditDB) SearchPosts(q models.SearchQuery, page models.Page) ([]*models.SearchResult, error) {
+	var rows []*struct {
+		models.Post
+		Snippet string `db:"snippet"`
+	}
+
+	args := []any{q.Query}
+	conditions, args := searchConditions(q, "u.username", "p.created", args)
+	conditions = append([]string{"p.search_vector @@ q", "NOT p.removed", notPrivateCommunity}, conditions...)
+	viewerConditions, args := viewerFilters(page.Viewer, "p.id", "p.author_id", args)
+	conditions = append(conditions, viewerConditions...)
+
+	cursorCondition, order, args := keyset("r.sort_key", "r.id", page, args)
+	if cursorCondition == "" {
+		cursorCondition = "TRUE"
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT r.*,
+            ts_headline('russian', %s, websearch_to_tsquery('russian', $1), %s) AS snippet
+        FROM (
+            SELECT %s,
+                ts_rank(p.search_vector, q)::float8 AS sort_key
+            FROM Posts p
+            JOIN Users u ON u.id = p.author_id
+            CROSS JOIN websearch_to_tsquery('russian', $1) q
+            WHERE %s
+        ) r
+        WHERE %s
+        ORDER BY r.sort_key %s, r.id %s
+        LIMIT $%d`, escapeHTML("coalesce(nullif(r.text, ''), r.title)"), headlineOptions, postColumns, strings.Join(conditions, " AND "), cursorCondition, order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске постов: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	results := make([]*models.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		post := row.Post
+		results = append(results, &models.SearchResult{
+			Type:      models.ItemPost,
+			Post:      &post,
+			PostID:    post.ID,
+			PostTitle: post.Title,
+			Rank:      post.SortKey,
+			Snippet:   row.Snippet,
+		})
+	}
+	return results, nil
+}
+
+func (s *RedditDB) SearchComments(q models.SearchQuery, page models.Page) ([]*models.SearchResult, error) {
+	var rows []*struct {
+		models.Comment
+		PostID    int     `db:"post_id"`
+		PostTitle string  `db:"post_title"`
+		SortKey   float64 `db:"sort_key"`
+		Snippet   string  `db:"snippet"`
+	}
+
+	args := []any{q.Query}
+	conditions, args := searchConditions(q, "u.username", "c.created", args)
+	conditions = append([]string{"c.search_vector @@ q", "NOT c.removed", "NOT p.removed", notPrivateCommunity}, conditions...)
+	viewerConditions, args := viewerFilters(page.Viewer, "p.id", "c.author_id", args)
+	conditions = append(conditions, viewerConditions...)
+
+	cursorCondition, order, args := keyset("r.sort_key", "r.id", page, args)
+	if cursorCondition == "" {
+		cursorCondition = "TRUE"
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT r.*,
+            ts_headline('russian', %s, websearch_to_tsquery('russian', $1), %s) AS snippet
+        FROM (
+            SELECT
+                c.id, c.parent_id, c.depth, c.body, c.created, c.edited, c.score,
+                u.id AS "author.id",
+                u.username AS "author.username",
+                p.id AS post_id,
+                p.title AS post_title,
+                ts_rank(c.search_vector, q)::float8 AS sort_key
+            FROM Comments c
+            JOIN Users u ON u.id = c.author_id
+            JOIN Posts p ON p.id = c.post_id
+            CROSS JOIN websearch_to_tsquery('russian', $1) q
+            WHERE %s
+        ) r
+        WHERE %s
+        ORDER BY r.sort_key %s, r.id %s
+        LIMIT $%d`, escapeHTML("r.body"), headlineOptions, strings.Join(conditions, " AND "), cursorCondition, order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске комментариев: %w", err)
+	}
+	if page.Before != nil {
+		slices.Reverse(rows)
+	}
+
+	results := make([]*models.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		comment := row.Comment
+		results = append(results, &models.SearchResult{
+			Type:      models.ItemComment,
+			Comment:   &comment,
+			PostID:    row.PostID,
+			PostTitle: row.PostTitle,
+			Rank:      row.SortKey,
+			Snippet:   row.Snippet,
+		})
+	}
+	return results, nil
+}
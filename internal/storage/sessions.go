@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("сессия не найдена")
+	ErrSessionExpired     = errors.New("срок действия сессии истек")
+	ErrRefreshTokenReused = errors.New("refresh-токен уже был использован, сессия отозвана")
+)
+
+func (s *RedditDB) CreateSession(session *models.Session) error {
+	query := `
+        INSERT INTO Sessions (family_id, user_id, token_hash, expires)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`
+	err := s.db.QueryOne(
+		context.Background(),
+		&session.ID,
+		query,
+		session.FamilyID,
+		session.UserID,
+		session.TokenHash,
+		session.Expires,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании сессии: %w", err)
+	}
+
+	return nil
+}
+
+// RotateSession помечает refresh-токен с хэшем oldHash использованным и сохраняет
+// newSession в том же семействе. Повторное предъявление уже использованного или
+// отозванного токена отзывает всё семейство.
+func (s *RedditDB) RotateSession(oldHash string, newSession *models.Session) (models.User, error) {
+	ctx := context.Background()
+	var user models.User
+	var reused bool
+
+	err := s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var old models.Session
+		querySession := `
+            SELECT id, family_id, user_id, token_hash, created, expires, used, revoked
+            FROM Sessions
+            WHERE token_hash = $1
+            FOR UPDATE`
+		err := tx.QueryOne(ctx, &old, querySession, oldHash)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrSessionNotFound
+			}
+			return fmt.Errorf("ошибка при поиске сессии: %w", err)
+		}
+
+		if old.Used || old.Revoked {
+			// Отзыв должен закоммититься, поэтому ошибку возвращаем уже после транзакции
+			reused = true
+			_, err = tx.Exec(ctx, `UPDATE Sessions SET revoked = TRUE WHERE family_id = $1`, old.FamilyID)
+			if err != nil {
+				return fmt.Errorf("ошибка при отзыве сессии: %w", err)
+			}
+			return nil
+		}
+
+		if old.Expires.Before(newSession.Created) {
+			return ErrSessionExpired
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE Sessions SET used = TRUE WHERE id = $1`, old.ID)
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении сессии: %w", err)
+		}
+
+		newSession.FamilyID = old.FamilyID
+		newSession.UserID = old.UserID
+		queryInsert := `
+            INSERT INTO Sessions (family_id, user_id, token_hash, expires)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id`
+		err = tx.QueryOne(ctx, &newSession.ID, queryInsert, newSession.FamilyID, newSession.UserID, newSession.TokenHash, newSession.Expires)
+		if err != nil {
+			return fmt.Errorf("ошибка при создании сессии: %w", err)
+		}
+
+		queryUser := `SELECT id, username, password, role FROM Users WHERE id = $1`
+		err = tx.QueryOne(ctx, &user, queryUser, newSession.UserID)
+		if err != nil {
+			return fmt.Errorf("ошибка при поиске пользователя: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return user, err
+	}
+	if reused {
+		return user, ErrRefreshTokenReused
+	}
+
+	return user, nil
+}
+
+func (s *RedditDB) RevokeSession(familyID string) error {
+	_, err := s.db.Exec(context.Background(), `UPDATE Sessions SET revoked = TRUE WHERE family_id = $1`, familyID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве сессии: %w", err)
+	}
+	return nil
+}
+
+func (s *RedditDB) IsSessionActive(familyID string) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS(SELECT 1 FROM Sessions WHERE family_id = $1 AND NOT revoked)`
+	err := s.db.QueryOne(context.Background(), &active, query, familyID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке сессии: %w", err)
+	}
+	return active, nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// userCommentColumns — столбцы комментария, его автора и поста для models.UserComment
+const userCommentColumns = `
+            c.id, c.parent_id, c.depth, c.body, c.created, c.edited, c.score,
+            (SELECT count(*) FROM Comments r WHERE r.parent_id = c.id) AS reply_count,
+            u.id AS "author.id",
+            u.username AS "author.username",
+            p.id AS post_id,
+            p.title AS post_title,
+            COALESCE(p.category, '') AS category`
+
+// GetUserProfile возвращает профиль пользователя с кармой и числом его постов
+// и комментариев. Удаленные записи и записи закрытых сообществ не учитываются.
+func (s *RedditDB) GetUserProfile(username string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	query := `
+        SELECT u.id, u.username, u.role, u.created,
+            COALESCE(posts.karma, 0) AS post_karma,
+            COALESCE(comments.karma, 0) AS comment_karma,
+            COALESCE(posts.count, 0) AS post_count,
+            COALESCE(comments.count, 0) AS comment_count
+        FROM Users u
+        LEFT JOIN LATERAL (
+            SELECT sum(p.score) AS karma, count(*) AS count
+            FROM Posts p
+            WHERE p.author_id = u.id AND p.deleted_at IS NULL AND NOT p.removed
+                AND ` + notPrivateCommunity + `
+        ) posts ON TRUE
+        LEFT JOIN LATERAL (
+            SELECT sum(c.score) AS karma, count(*) AS count
+            FROM Comments c
+            JOIN Posts p ON p.id = c.post_id
+            WHERE c.author_id = u.id AND c.deleted_at IS NULL AND NOT c.removed
+                AND ` + notPrivateCommunity + `
+        ) comments ON TRUE
+        WHERE u.username = $1`
+	err := s.db.QueryOne(context.Background(), &profile, query, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении профиля: %w", err)
+	}
+	return &profile, nil
+}
+
+// GetVotedPosts возвращает посты, за которые пользователь проголосовал со значением vote
+func (s *RedditDB) GetVotedPosts(userID int, vote int, page models.Page) ([]*models.Post, error) {
+	where := `EXISTS (SELECT 1 FROM Votes v WHERE v.post_id = p.id AND v.user_id = $1 AND v.vote = $2) AND ` + notPrivateCommunity
+	posts, err := s.listPosts(context.Background(), where, []any{userID, vote}, page)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске постов по голосам пользователя: %w", err)
+	}
+
+	return posts, nil
+}
+
+// GetVotedComments возвращает комментарии, за которые пользователь проголосовал
+// со значением vote, новые первыми
+func (s *RedditDB) GetVotedComments(userID int, vote int, page models.Page) ([]*models.UserComment, error) {
+	var comments []*models.UserComment
+
+	const key = "extract(epoch FROM c.created)::float8"
+	conditions := []string{
+		"EXISTS (SELECT 1 FROM CommentVotes v WHERE v.comment_id = c.id AND v.user_id = $1 AND v.vote = $2)",
+		"NOT c.removed", "c.deleted_at IS NULL",
+		"NOT p.removed", "p.deleted_at IS NULL",
+		notPrivateCommunity,
+	}
+	cursorCondition, order, args := keyset(key, "c.id", page, []any{userID, vote})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT %s,
+            %s AS sort_key
+        FROM Comments c
+        JOIN Users u ON u.id = c.author_id
+        JOIN Posts p ON p.id = c.post_id
+        WHERE %s
+        ORDER BY sort_key %s, c.id %s
+        LIMIT $%d`, userCommentColumns, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &comments, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске комментариев по голосам пользователя: %w", err)
+	}
+
+	if page.Before != nil {
+		slices.Reverse(comments)
+	}
+
+	return comments, nil
+}
+
+// GetCommentsByUserLogin возвращает комментарии пользователя вместе с ID и
+// заголовком поста. page.Sort — CommentSortNew или CommentSortTop.
+func (s *RedditDB) GetCommentsByUserLogin(username string, page models.Page) ([]*models.UserComment, error) {
+	var comments []*models.UserComment
+
+	key := "extract(epoch FROM c.created)::float8"
+	if page.Sort == models.CommentSortTop {
+		key = "c.score::float8"
+	}
+
+	conditions := []string{
+		"u.username = $1",
+		"NOT c.removed", "c.deleted_at IS NULL",
+		"NOT p.removed", "p.deleted_at IS NULL",
+		notPrivateCommunity,
+	}
+	cursorCondition, order, args := keyset(key, "c.id", page, []any{username})
+	if cursorCondition != "" {
+		conditions = append(conditions, cursorCondition)
+	}
+
+	args = append(args, page.Limit)
+	query := fmt.Sprintf(`
+        SELECT %s,
+            %s AS sort_key
+        FROM Comments c
+        JOIN Users u ON u.id = c.author_id
+        JOIN Posts p ON p.id = c.post_id
+        WHERE %s
+        ORDER BY sort_key %s, c.id %s
+        LIMIT $%d`, userCommentColumns, key, strings.Join(conditions, " AND "), order, order, len(args))
+
+	err := s.db.QueryMany(context.Background(), &comments, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске комментариев пользователя: %w", err)
+	}
+
+	if page.Before != nil {
+		slices.Reverse(comments)
+	}
+
+	return comments, nil
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit_v2/internal/models"
+	"reddit_v2/internal/pg"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// voteTarget описывает, где хранятся голоса за объект и где лежит его score
+type voteTarget struct {
+	items  string // таблица объектов со столбцом score
+	votes  string // таблица голосов
+	column string // столбец с ID объекта в таблице голосов
+	name   string // название объекта для сообщений об ошибках
+}
+
+var (
+	postVotes    = voteTarget{items: "Posts", votes: "Votes", column: "post_id", name: "поста"}
+	commentVotes = voteTarget{items: "Comments", votes: "CommentVotes", column: "comment_id", name: "комментария"}
+)
+
+// applyVote в одной транзакции сохраняет голос пользователя и сдвигает score
+// объекта на разницу между новым и прежним голосом
+func (s *RedditDB) applyVote(ctx context.Context, target voteTarget, itemID int, vote *models.Vote) error {
+	return s.db.WithTx(ctx, func(tx pg.Tx) error {
+		var existingVote int
+		var voteChange int
+
+		queryCheck := fmt.Sprintf(`SELECT COALESCE(vote, 0) FROM %s WHERE user_id = $1 AND %s = $2`, target.votes, target.column)
+		err := tx.QueryOne(ctx, &existingVote, queryCheck, vote.User, itemID)
+
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ошибка при поиске голоса: %w", err)
+		}
+
+		if existingVote == vote.Vote {
+			return nil
+		}
+
+		upsertVoteQuery := fmt.Sprintf(`
+            INSERT INTO %[1]s (user_id, %[2]s, vote)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (user_id, %[2]s) DO UPDATE SET vote = EXCLUDED.vote`, target.votes, target.column)
+		_, err = tx.Exec(ctx, upsertVoteQuery, vote.User, itemID, vote.Vote)
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении/вставке голоса: %w", err)
+		}
+
+		voteChange = vote.Vote - existingVote
+		upvoteChange := boolToInt(vote.Vote > 0) - boolToInt(existingVote > 0)
+		downvoteChange := boolToInt(vote.Vote < 0) - boolToInt(existingVote < 0)
+
+		if voteChange != 0 {
+			scoreUpdate := fmt.Sprintf(`
+                UPDATE %s
+                SET score = score + $1, upvotes = upvotes + $2, downvotes = downvotes + $3
+                WHERE id = $4`, target.items)
+			_, err = tx.Exec(ctx, scoreUpdate, voteChange, upvoteChange, downvoteChange, itemID)
+			if err != nil {
+				return fmt.Errorf("ошибка при обновлении score %s: %w", target.name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (s *RedditDB) UpdateCommentVote(idPost int, commentID int, vote *models.Vote) (*models.Post, error) {
+	ctx := context.Background()
+
+	var commentExists bool
+	query := "SELECT EXISTS(SELECT 1 FROM Comments WHERE id = $1 AND post_id = $2)"
+	err := s.db.QueryOne(ctx, &commentExists, query, commentID, idPost)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке существования комментария: %w", err)
+	}
+	if !commentExists {
+		return nil, ErrCommentNotFound
+	}
+
+	err = s.applyVote(ctx, commentVotes, commentID, vote)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPost(idPost)
+}
+
+// GetCommentVotesByUser возвращает голоса пользователя за комментарии поста: ID комментария → голос
+func (s *RedditDB) GetCommentVotesByUser(postID int, userID int) (map[int]int, error) {
+	var votes []struct {
+		CommentID int `db:"comment_id"`
+		Vote      int `db:"vote"`
+	}
+	query := `
+        SELECT v.comment_id, v.vote
+        FROM CommentVotes v
+        JOIN Comments c ON c.id = v.comment_id
+        WHERE c.post_id = $1 AND v.user_id = $2 AND v.vote <> 0`
+	err := s.db.QueryMany(context.Background(), &votes, query, postID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении голосов за комментарии: %w", err)
+	}
+
+	result := make(map[int]int, len(votes))
+	for _, v := range votes {
+		result[v.CommentID] = v.Vote
+	}
+	return result, nil
+}
+
+// GetVotes одним запросом загружает голоса за несколько постов: ID поста → голоса
+func (s *RedditDB) GetVotes(postIDs []int) (map[int][]models.Vote, error) {
+	var votes []struct {
+		PostID int `db:"post_id"`
+		User   int `db:"user_id"`
+		Vote   int `db:"vote"`
+	}
+	query := `
+        SELECT post_id, user_id, vote
+        FROM Votes
+        WHERE post_id = ANY($1) AND vote <> 0
+        ORDER BY id`
+	err := s.db.QueryMany(context.Background(), &votes, query, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении голосов: %w", err)
+	}
+
+	result := make(map[int][]models.Vote, len(postIDs))
+	for _, v := range votes {
+		result[v.PostID] = append(result[v.PostID], models.Vote{User: v.User, Vote: v.Vote})
+	}
+	return result, nil
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Сессии пользователей: одна строка на каждый выданный refresh-токен.
+-- Все токены, полученные ротацией из одного логина, имеют общий family_id.
+CREATE TABLE IF NOT EXISTS Sessions (
+    id SERIAL PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL,
+    user_id INT REFERENCES Users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires TIMESTAMP NOT NULL,
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    revoked BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS sessions_family_id_idx ON Sessions (family_id);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS sessions_family_id_idx;
+DROP TABLE IF EXISTS Sessions;
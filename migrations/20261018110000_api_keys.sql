@@ -0,0 +1,15 @@
+-- +goose Up
+-- API-ключи для ботов и скриптов. Хранится только SHA-256 от ключа.
+CREATE TABLE IF NOT EXISTS ApiKeys (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES Users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used TIMESTAMP,
+    revoked BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS ApiKeys;
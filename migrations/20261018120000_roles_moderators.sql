@@ -0,0 +1,18 @@
+-- +goose Up
+-- Роль пользователя на уровне сайта
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE Users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Модераторы категорий
+CREATE TABLE IF NOT EXISTS Moderators (
+    user_id INT REFERENCES Users(id) ON DELETE CASCADE,
+    category VARCHAR(255) NOT NULL,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, category)
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Moderators;
+ALTER TABLE Users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE Users DROP COLUMN IF EXISTS role;
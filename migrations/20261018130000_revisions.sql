@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE Posts ADD COLUMN IF NOT EXISTS edited TIMESTAMP;
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS edited TIMESTAMP;
+
+-- Предыдущие версии постов и комментариев. Строка сохраняется перед каждой правкой.
+CREATE TABLE IF NOT EXISTS Revisions (
+    id SERIAL PRIMARY KEY,
+    item_type VARCHAR(10) NOT NULL CHECK (item_type IN ('post', 'comment')),
+    item_id INT NOT NULL,
+    editor_id INT REFERENCES Users(id) ON DELETE SET NULL,
+    title VARCHAR(255),
+    url VARCHAR(255),
+    body TEXT NOT NULL,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS revisions_item_idx ON Revisions (item_type, item_id, created);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS revisions_item_idx;
+DROP TABLE IF EXISTS Revisions;
+ALTER TABLE Comments DROP COLUMN IF EXISTS edited;
+ALTER TABLE Posts DROP COLUMN IF EXISTS edited;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Ответы на комментарии. depth хранится, чтобы не пересчитывать глубину при каждой вставке.
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES Comments(id) ON DELETE CASCADE;
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS depth INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS comments_post_id_idx ON Comments (post_id);
+CREATE INDEX IF NOT EXISTS comments_parent_id_idx ON Comments (parent_id);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS comments_parent_id_idx;
+DROP INDEX IF EXISTS comments_post_id_idx;
+ALTER TABLE Comments DROP COLUMN IF EXISTS depth;
+ALTER TABLE Comments DROP COLUMN IF EXISTS parent_id;
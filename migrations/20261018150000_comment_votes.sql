@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS score INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS CommentVotes (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES Users(id) ON DELETE CASCADE,
+    comment_id INT REFERENCES Comments(id) ON DELETE CASCADE,
+    vote INT NOT NULL,
+    CONSTRAINT unique_user_comment UNIQUE (user_id, comment_id)
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS CommentVotes;
+ALTER TABLE Comments DROP COLUMN IF EXISTS score;
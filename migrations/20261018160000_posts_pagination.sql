@@ -0,0 +1,11 @@
+-- +goose Up
+-- Индексы под keyset-пагинацию: выражение совпадает с ключом сортировки в storage
+CREATE INDEX IF NOT EXISTS posts_new_idx ON Posts ((extract(epoch FROM created)::float8) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_category_new_idx ON Posts (category, (extract(epoch FROM created)::float8) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_author_new_idx ON Posts (author_id, (extract(epoch FROM created)::float8) DESC, id DESC);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_author_new_idx;
+DROP INDEX IF EXISTS posts_category_new_idx;
+DROP INDEX IF EXISTS posts_new_idx;
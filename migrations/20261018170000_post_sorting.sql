@@ -0,0 +1,52 @@
+-- +goose Up
+-- Счетчики голосов «за» и «против» нужны для сортировки controversial
+ALTER TABLE Posts ADD COLUMN IF NOT EXISTS upvotes INT NOT NULL DEFAULT 0;
+ALTER TABLE Posts ADD COLUMN IF NOT EXISTS downvotes INT NOT NULL DEFAULT 0;
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS upvotes INT NOT NULL DEFAULT 0;
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS downvotes INT NOT NULL DEFAULT 0;
+
+UPDATE Posts p SET
+    upvotes = (SELECT count(*) FROM Votes v WHERE v.post_id = p.id AND v.vote > 0),
+    downvotes = (SELECT count(*) FROM Votes v WHERE v.post_id = p.id AND v.vote < 0);
+UPDATE Comments c SET
+    upvotes = (SELECT count(*) FROM CommentVotes v WHERE v.comment_id = c.id AND v.vote > 0),
+    downvotes = (SELECT count(*) FROM CommentVotes v WHERE v.comment_id = c.id AND v.vote < 0);
+
+-- Формулы Reddit. Функции не зависят от текущего времени, поэтому по ним можно строить индексы.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION hot_rank(score INT, created TIMESTAMP) RETURNS float8 AS $$
+    SELECT sign(score)::float8 * log(greatest(abs(score), 1)::float8)
+         + (extract(epoch FROM created)::float8 - 1134028003) / 45000
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION controversy_rank(upvotes INT, downvotes INT) RETURNS float8 AS $$
+    SELECT CASE
+        WHEN upvotes <= 0 OR downvotes <= 0 THEN 0::float8
+        ELSE power((upvotes + downvotes)::float8, least(upvotes, downvotes)::float8 / greatest(upvotes, downvotes))
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+CREATE INDEX IF NOT EXISTS posts_hot_idx ON Posts (hot_rank(score, created) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_category_hot_idx ON Posts (category, hot_rank(score, created) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_top_idx ON Posts ((score::float8) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_category_top_idx ON Posts (category, (score::float8) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_controversial_idx ON Posts (controversy_rank(upvotes, downvotes) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_created_idx ON Posts (created);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_created_idx;
+DROP INDEX IF EXISTS posts_controversial_idx;
+DROP INDEX IF EXISTS posts_category_top_idx;
+DROP INDEX IF EXISTS posts_top_idx;
+DROP INDEX IF EXISTS posts_category_hot_idx;
+DROP INDEX IF EXISTS posts_hot_idx;
+DROP FUNCTION IF EXISTS controversy_rank(INT, INT);
+DROP FUNCTION IF EXISTS hot_rank(INT, TIMESTAMP);
+ALTER TABLE Comments DROP COLUMN IF EXISTS downvotes;
+ALTER TABLE Comments DROP COLUMN IF EXISTS upvotes;
+ALTER TABLE Posts DROP COLUMN IF EXISTS downvotes;
+ALTER TABLE Posts DROP COLUMN IF EXISTS upvotes;
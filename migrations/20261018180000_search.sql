@@ -0,0 +1,19 @@
+-- +goose Up
+-- Полнотекстовый поиск. Конфигурация russian обрабатывает и латиницу английским стеммером.
+ALTER TABLE Posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(text, '')), 'B')
+    ) STORED;
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', coalesce(body, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS posts_search_idx ON Posts USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS comments_search_idx ON Comments USING GIN (search_vector);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS comments_search_idx;
+DROP INDEX IF EXISTS posts_search_idx;
+ALTER TABLE Comments DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE Posts DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS Communities (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    creator_id INT REFERENCES Users(id) ON DELETE SET NULL,
+    rules TEXT[] NOT NULL DEFAULT '{}',
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'restricted', 'private')),
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Категории, которые предлагает фронтенд
+INSERT INTO Communities (name) VALUES
+    ('music'), ('funny'), ('videos'), ('programming'), ('news'), ('fashion')
+ON CONFLICT (name) DO NOTHING;
+
+-- Переносим категории, которые уже встречаются в постах и у модераторов.
+-- Посты с пустой категорией остаются без сообщества.
+UPDATE Posts SET category = NULL WHERE category = '';
+INSERT INTO Communities (name)
+SELECT DISTINCT category FROM Posts WHERE category IS NOT NULL
+ON CONFLICT (name) DO NOTHING;
+INSERT INTO Communities (name)
+SELECT DISTINCT category FROM Moderators
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE Posts ADD CONSTRAINT posts_category_fkey
+    FOREIGN KEY (category) REFERENCES Communities(name) ON UPDATE CASCADE;
+ALTER TABLE Moderators ADD CONSTRAINT moderators_category_fkey
+    FOREIGN KEY (category) REFERENCES Communities(name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+-- +goose Down
+ALTER TABLE Moderators DROP CONSTRAINT IF EXISTS moderators_category_fkey;
+ALTER TABLE Posts DROP CONSTRAINT IF EXISTS posts_category_fkey;
+DROP TABLE IF EXISTS Communities;
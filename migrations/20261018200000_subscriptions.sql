@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS Subscriptions (
+    user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    community_id INT NOT NULL REFERENCES Communities(id) ON DELETE CASCADE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, community_id)
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_community_idx ON Subscriptions (community_id);
+
+-- Счетчик подписчиков хранится в сообществе, чтобы не считать его при каждом запросе
+ALTER TABLE Communities ADD COLUMN IF NOT EXISTS subscriber_count INT NOT NULL DEFAULT 0;
+
+
+-- +goose Down
+ALTER TABLE Communities DROP COLUMN IF EXISTS subscriber_count;
+DROP TABLE IF EXISTS Subscriptions;
@@ -0,0 +1,37 @@
+-- +goose Up
+ALTER TABLE Posts
+    ADD COLUMN IF NOT EXISTS removed BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS approved BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE Comments
+    ADD COLUMN IF NOT EXISTS removed BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS approved BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Закрепленные посты категории и очередь модерации
+CREATE INDEX IF NOT EXISTS posts_category_pinned_idx ON Posts (category) WHERE pinned;
+CREATE INDEX IF NOT EXISTS posts_unmoderated_idx ON Posts (category, (extract(epoch FROM created)::float8) DESC, id DESC) WHERE NOT removed AND NOT approved;
+CREATE INDEX IF NOT EXISTS comments_unmoderated_idx ON Comments (post_id, (extract(epoch FROM created)::float8) DESC, id DESC) WHERE NOT removed AND NOT approved;
+
+CREATE TABLE IF NOT EXISTS ModLog (
+    id SERIAL PRIMARY KEY,
+    actor_id INT REFERENCES Users(id) ON DELETE SET NULL,
+    category VARCHAR(255) REFERENCES Communities(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    action VARCHAR(50) NOT NULL,
+    item_type VARCHAR(20) NOT NULL,
+    item_id INT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS modlog_category_created_idx ON ModLog (category, created DESC, id DESC);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS ModLog;
+DROP INDEX IF EXISTS comments_unmoderated_idx;
+DROP INDEX IF EXISTS posts_unmoderated_idx;
+DROP INDEX IF EXISTS posts_category_pinned_idx;
+ALTER TABLE Comments DROP COLUMN IF EXISTS approved, DROP COLUMN IF EXISTS removed;
+ALTER TABLE Posts DROP COLUMN IF EXISTS pinned, DROP COLUMN IF EXISTS locked, DROP COLUMN IF EXISTS approved, DROP COLUMN IF EXISTS removed;
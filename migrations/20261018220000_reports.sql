@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS Reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    item_type VARCHAR(20) NOT NULL CHECK (item_type IN ('post', 'comment')),
+    item_id INT NOT NULL,
+    post_id INT NOT NULL REFERENCES Posts(id) ON DELETE CASCADE,
+    category VARCHAR(255) REFERENCES Communities(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    resolved_by INT REFERENCES Users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (reporter_id, item_type, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS reports_item_idx ON Reports (item_type, item_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS reports_open_idx ON Reports (category, (extract(epoch FROM created)::float8) DESC, id DESC) WHERE status = 'open';
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Reports;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Бан без категории действует на весь сайт, без expires — бессрочно
+CREATE TABLE IF NOT EXISTS Bans (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    category VARCHAR(255) REFERENCES Communities(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    banned_by INT REFERENCES Users(id) ON DELETE SET NULL,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS bans_user_category_idx ON Bans (user_id, (COALESCE(category, '')));
+CREATE INDEX IF NOT EXISTS bans_category_idx ON Bans (category);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Bans;
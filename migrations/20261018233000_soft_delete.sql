@@ -0,0 +1,15 @@
+-- +goose Up
+-- Удаленные посты и комментарии остаются в таблицах с пустым текстом,
+-- пока их не очистит периодическая задача
+ALTER TABLE Posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE Comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS posts_deleted_idx ON Posts (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS comments_deleted_idx ON Comments (deleted_at) WHERE deleted_at IS NOT NULL;
+
+
+-- +goose Down
+DROP INDEX IF EXISTS comments_deleted_idx;
+DROP INDEX IF EXISTS posts_deleted_idx;
+ALTER TABLE Comments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE Posts DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Дата регистрации уже существующих пользователей неизвестна, поэтому
+-- берется дата их первого поста или комментария
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS created TIMESTAMP;
+
+UPDATE Users u
+SET created = COALESCE(
+    (SELECT least(
+        (SELECT min(p.created) FROM Posts p WHERE p.author_id = u.id),
+        (SELECT min(c.created) FROM Comments c WHERE c.author_id = u.id)
+    )),
+    CURRENT_TIMESTAMP
+)
+WHERE created IS NULL;
+
+ALTER TABLE Users ALTER COLUMN created SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE Users ALTER COLUMN created SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS votes_user_idx ON Votes (user_id, vote);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS votes_user_idx;
+ALTER TABLE Users DROP COLUMN IF EXISTS created;
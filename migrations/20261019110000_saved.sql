@@ -0,0 +1,18 @@
+-- +goose Up
+-- Сохраненные пользователем посты и комментарии. post_id — пост самой записи
+-- или пост, к которому оставлен комментарий.
+CREATE TABLE IF NOT EXISTS Saved (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    item_type VARCHAR(20) NOT NULL CHECK (item_type IN ('post', 'comment')),
+    item_id INT NOT NULL,
+    post_id INT NOT NULL REFERENCES Posts(id) ON DELETE CASCADE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, item_type, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS saved_user_idx ON Saved (user_id, (extract(epoch FROM created)::float8) DESC, id DESC);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Saved;
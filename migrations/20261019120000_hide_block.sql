@@ -0,0 +1,22 @@
+-- +goose Up
+-- Посты, которые пользователь скрыл из своих лент
+CREATE TABLE IF NOT EXISTS HiddenPosts (
+    user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    post_id INT NOT NULL REFERENCES Posts(id) ON DELETE CASCADE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- Авторы, чьи записи пользователь не хочет видеть
+CREATE TABLE IF NOT EXISTS Blocks (
+    user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    blocked_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, blocked_id),
+    CHECK (user_id <> blocked_id)
+);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Blocks;
+DROP TABLE IF EXISTS HiddenPosts;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS Notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL CHECK (type IN ('post_reply', 'comment_reply', 'mention', 'post_milestone', 'comment_milestone')),
+    actor_id INT REFERENCES Users(id) ON DELETE SET NULL,
+    post_id INT NOT NULL REFERENCES Posts(id) ON DELETE CASCADE,
+    comment_id INT REFERENCES Comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL DEFAULT '',
+    milestone INT,
+    read BOOLEAN NOT NULL DEFAULT FALSE,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx ON Notifications (user_id, (extract(epoch FROM created)::float8) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON Notifications (user_id) WHERE NOT read;
+-- Каждая отметка оценки записи уведомляет автора только один раз
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_milestone_idx
+    ON Notifications (post_id, (COALESCE(comment_id, 0)), milestone) WHERE milestone IS NOT NULL;
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Notifications;
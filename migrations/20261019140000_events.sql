@@ -0,0 +1,20 @@
+-- +goose Up
+-- Журнал событий для потоков обновлений. Экземпляры приложения узнают о новых
+-- событиях через NOTIFY, а клиенты после переподключения дочитывают пропущенное
+-- по Last-Event-ID.
+CREATE TABLE IF NOT EXISTS Events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('comment', 'score', 'delete')),
+    post_id INT NOT NULL REFERENCES Posts(id) ON DELETE CASCADE,
+    category VARCHAR(255) REFERENCES Communities(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    data JSONB NOT NULL,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS events_post_idx ON Events (post_id, id);
+CREATE INDEX IF NOT EXISTS events_category_idx ON Events (category, id);
+CREATE INDEX IF NOT EXISTS events_created_idx ON Events (created);
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Events;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Личные сообщения. Каждый участник удаляет сообщение только у себя;
+-- удаленное обоими сообщение стирается из таблицы.
+CREATE TABLE IF NOT EXISTS Messages (
+    id SERIAL PRIMARY KEY,
+    sender_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    recipient_id INT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP,
+    sender_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    recipient_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    CHECK (sender_id <> recipient_id)
+);
+
+CREATE INDEX IF NOT EXISTS messages_sender_idx ON Messages (sender_id, created);
+CREATE INDEX IF NOT EXISTS messages_recipient_idx ON Messages (recipient_id, created);
+CREATE INDEX IF NOT EXISTS messages_unread_idx ON Messages (recipient_id) WHERE read_at IS NULL;
+
+
+-- +goose Down
+DROP TABLE IF EXISTS Messages;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Текст уведомления берется из самого комментария, чтобы удаленный или
+-- снятый модератором комментарий не оставался читаемым в уведомлениях
+ALTER TABLE Notifications DROP COLUMN IF EXISTS body;
+
+
+-- +goose Down
+ALTER TABLE Notifications ADD COLUMN IF NOT EXISTS body TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Позиция события в потоке — ID транзакции, которая его опубликовала.
+-- Читатели видят только события транзакций младше горизонта снимка
+-- (pg_snapshot_xmin): к этому моменту все транзакции с меньшим ID уже
+-- завершены, поэтому события становятся видимыми строго по возрастанию
+-- позиции без общей блокировки. Журнал хранит события только для
+-- переподключения, прежние записи без позиции просто отбрасываются.
+DELETE FROM Events;
+ALTER TABLE Events ADD COLUMN tx xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+DROP INDEX IF EXISTS events_post_idx;
+DROP INDEX IF EXISTS events_category_idx;
+CREATE INDEX IF NOT EXISTS events_tx_idx ON Events (tx);
+CREATE INDEX IF NOT EXISTS events_post_idx ON Events (post_id, tx);
+CREATE INDEX IF NOT EXISTS events_category_idx ON Events (category, tx);
+
+
+-- +goose Down
+DROP INDEX IF EXISTS events_tx_idx;
+DROP INDEX IF EXISTS events_post_idx;
+DROP INDEX IF EXISTS events_category_idx;
+ALTER TABLE Events DROP COLUMN IF EXISTS tx;
+CREATE INDEX IF NOT EXISTS events_post_idx ON Events (post_id, id);
+CREATE INDEX IF NOT EXISTS events_category_idx ON Events (category, id);